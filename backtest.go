@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/backtest"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	"github.com/arjunrn/simple-scaler/pkg/timeseries"
+	prometheus_api "github.com/prometheus/client_golang/api"
+	"io"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"os"
+	"time"
+)
+
+// runBacktest implements the backtest subcommand. It replays the recorded demand of the target
+// of a Scaler through the replica calculator and prints the scaling events and a summary.
+func runBacktest(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("backtest", flag.ExitOnError)
+	var (
+		scalerFile string
+		input      string
+		promURL    string
+		selector   string
+		startFlag  string
+		endFlag    string
+		replicas   int
+		cooldown   time.Duration
+		timeline   bool
+	)
+	flags.StringVar(&scalerFile, "scaler", "", "Path to the Scaler YAML to test")
+	flags.StringVar(&input, "input", "", "CSV or JSON file with the demand of the target in percent of the request of one pod")
+	flags.StringVar(&promURL, "prometheus-url", "", "Fetch the demand of the target from this prometheus server instead of a file")
+	flags.StringVar(&selector, "selector", "", "Label selector of the pods of the target. Required with -prometheus-url")
+	flags.StringVar(&startFlag, "start", "", "Start of the replay in RFC3339. Required with -prometheus-url")
+	flags.StringVar(&endFlag, "end", "", "End of the replay in RFC3339. Defaults to now with -prometheus-url")
+	flags.IntVar(&replicas, "replicas", 0, "Replicas at the start of the replay. Defaults to the minimum replicas")
+	flags.DurationVar(&cooldown, "cooldown", backtest.DefaultCooldown, "Minimum time between two scaling actions")
+	flags.BoolVar(&timeline, "timeline", true, "Print every scaling event")
+	flags.Parse(args)
+
+	if scalerFile == "" || (input == "") == (promURL == "") {
+		return fmt.Errorf("-scaler and either -input or -prometheus-url are required")
+	}
+	scaler, err := loadScaler(scalerFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the Scaler: %v", err)
+	}
+	start, end, err := replayRange(startFlag, endFlag, promURL != "")
+	if err != nil {
+		return err
+	}
+
+	var series timeseries.Series
+	if input != "" {
+		if series, err = timeseries.Load(input); err != nil {
+			return fmt.Errorf("failed to read %s: %v", input, err)
+		}
+	} else if series, err = fetchDemand(promURL, selector, scaler, start, end); err != nil {
+		return fmt.Errorf("failed to fetch the demand from prometheus: %v", err)
+	}
+	series = series.Between(start, end)
+	if len(series) == 0 {
+		return fmt.Errorf("no samples in the replayed range")
+	}
+
+	initial := int32(replicas)
+	if initial == 0 {
+		initial = scaler.Spec.MinReplicas
+	}
+	if initial < 1 {
+		initial = 1
+	}
+	result, err := backtest.Replay(scaler.Spec, series.Resample(backtest.Step), series[0].Time, initial, cooldown)
+	if err != nil {
+		return err
+	}
+	printResult(out, scaler, result, timeline)
+	return nil
+}
+
+func loadScaler(path string) (*v1alpha1.Scaler, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scaler := &v1alpha1.Scaler{}
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(scaler); err != nil {
+		return nil, err
+	}
+	return scaler, nil
+}
+
+// replayRange parses the start and the end of the replay. Both are optional for files.
+func replayRange(startFlag, endFlag string, required bool) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if startFlag != "" {
+		if start, err = time.Parse(time.RFC3339, startFlag); err != nil {
+			return start, end, fmt.Errorf("invalid start: %v", err)
+		}
+	} else if required {
+		return start, end, fmt.Errorf("-start is required with -prometheus-url")
+	}
+	if endFlag != "" {
+		if end, err = time.Parse(time.RFC3339, endFlag); err != nil {
+			return start, end, fmt.Errorf("invalid end: %v", err)
+		}
+	} else if required {
+		end = time.Now()
+	}
+	return start, end, nil
+}
+
+// fetchDemand queries the demand of the pods of the target in steps of the replay
+func fetchDemand(url, selectorFlag string, scaler *v1alpha1.Scaler, start, end time.Time) (timeseries.Series, error) {
+	if selectorFlag == "" {
+		return nil, fmt.Errorf("-selector is required with -prometheus-url")
+	}
+	selector, err := labels.Parse(selectorFlag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %v", err)
+	}
+	client, err := prometheus_api.NewClient(prometheus_api.Config{Address: url})
+	if err != nil {
+		return nil, err
+	}
+	source := replicacalculator.NewPrometheusMetricsSource(client).(replicacalculator.DemandSource)
+	start = start.Truncate(backtest.Step)
+	values, err := source.GetDemandHistory(scaler.Namespace, selector, start, end, backtest.Step)
+	if err != nil {
+		return nil, err
+	}
+	series := make(timeseries.Series, len(values))
+	for i, v := range values {
+		series[i] = timeseries.Sample{Time: start.Add(time.Duration(i) * backtest.Step), Value: v}
+	}
+	return series, nil
+}
+
+func printResult(out io.Writer, scaler *v1alpha1.Scaler, result *backtest.Result, timeline bool) {
+	if timeline {
+		for _, e := range result.Events {
+			fmt.Fprintf(out, "%s  %3d -> %3d  utilization %d%%\n", e.Time.Format(time.RFC3339), e.FromReplicas,
+				e.ToReplicas, e.Utilization)
+		}
+		fmt.Fprintln(out)
+	}
+	percent := func(d time.Duration) float64 {
+		return 100 * float64(d) / float64(result.Duration)
+	}
+	fmt.Fprintf(out, "Scaler:          %s (min %d, max %d, up %d%%, down %d%%)\n", scaler.Name,
+		scaler.Spec.MinReplicas, scaler.Spec.MaxReplicas, scaler.Spec.ScaleUp, scaler.Spec.ScaleDown)
+	fmt.Fprintf(out, "Replayed:        %s\n", result.Duration)
+	fmt.Fprintf(out, "Scale events:    %d (%d up, %d down)\n", len(result.Events), result.ScaleUps, result.ScaleDowns)
+	fmt.Fprintf(out, "Blocked:         %d evaluations outside the bounds\n", result.Blocked)
+	fmt.Fprintf(out, "Peak replicas:   %d\n", result.PeakReplicas)
+	fmt.Fprintf(out, "Time at max:     %s (%.1f%%)\n", result.AtMax, percent(result.AtMax))
+	fmt.Fprintf(out, "Time at min:     %s (%.1f%%)\n", result.AtMin, percent(result.AtMin))
+	fmt.Fprintf(out, "Overloaded:      %s (%.1f%%)\n", result.Overloaded, percent(result.Overloaded))
+	fmt.Fprintf(out, "Pod hours:       %.1f\n", result.PodHours)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	clientset "github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned"
+	"github.com/spf13/pflag"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// now is replaced in the tests
+var now = time.Now
+
+func listCommand(flags *pflag.FlagSet) runFunc {
+	allNamespaces := flags.BoolP("all-namespaces", "A", false, "List the Scalers in all namespaces")
+	return func(client clientset.Interface, namespace string, args []string, out io.Writer) error {
+		if *allNamespaces {
+			namespace = metav1.NamespaceAll
+		}
+		return runList(client, namespace, *allNamespaces, out)
+	}
+}
+
+// runList prints a line for every Scaler. The utilization is the one of the last decision in
+// the history, the controller does not record it otherwise.
+func runList(client clientset.Interface, namespace string, allNamespaces bool, out io.Writer) error {
+	list, err := client.ArjunnaikV1alpha1().Scalers(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	if allNamespaces {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tTARGET\tMIN\tMAX\tCURRENT\tDESIRED\tUTILIZATION\tSTATE\tAGE")
+	for _, s := range list.Items {
+		if allNamespaces {
+			fmt.Fprintf(w, "%s\t", s.Namespace)
+		}
+		fmt.Fprintf(w, "%s\t%s/%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n", s.Name, s.Spec.Target.Kind, s.Spec.Target.Name,
+			s.Spec.MinReplicas, s.Spec.MaxReplicas, s.Status.CurrentReplicas, s.Status.DesiredReplicas,
+			lastUtilization(&s), strings.Join(scalerState(&s), ","), age(s.CreationTimestamp))
+	}
+	return w.Flush()
+}
+
+func runDescribe(client clientset.Interface, namespace string, args []string, out io.Writer) error {
+	s, err := getScaler(client, namespace, args)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", s.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", s.Namespace)
+	fmt.Fprintf(w, "Target:\t%s/%s (%s)\n", s.Spec.Target.Kind, s.Spec.Target.Name, s.Spec.Target.APIVersion)
+	fmt.Fprintf(w, "Replicas:\tcurrent %d, desired %d, min %d, max %d\n", s.Status.CurrentReplicas,
+		s.Status.DesiredReplicas, s.Spec.MinReplicas, s.Spec.MaxReplicas)
+	fmt.Fprintf(w, "Thresholds:\tup %d%% by %d, down %d%% by %d, for %d evaluations\n", s.Spec.ScaleUp,
+		s.Spec.ScaleUpSize, s.Spec.ScaleDown, s.Spec.ScaleDownSize, s.Spec.Evaluations)
+	fmt.Fprintf(w, "State:\t%s\n", strings.Join(scalerState(s), ", "))
+	fmt.Fprintf(w, "Utilization:\t%s\n", lastUtilization(s))
+	if s.Status.LastScalingTimestamp != "" {
+		fmt.Fprintf(w, "Last scaling:\t%s\n", s.Status.LastScalingTimestamp)
+	}
+	if s.Status.Reason != "" {
+		fmt.Fprintf(w, "Reason:\t%s\n", s.Status.Reason)
+	}
+	if s.Status.ActiveSchedule != "" {
+		fmt.Fprintf(w, "Active schedule:\t%s\n", s.Status.ActiveSchedule)
+	}
+	if f := s.Status.Forecast; f != nil {
+		fmt.Fprintf(w, "Forecast:\tdemand %d%%, %d replicas, %d%% confidence (%s ago)\n", f.Demand, f.Replicas,
+			f.Confidence, age(f.Time))
+	}
+	w.Flush()
+
+	fmt.Fprintln(out, "\nOverrides:")
+	if len(s.Status.Overrides) == 0 {
+		fmt.Fprintln(out, "  <none>")
+	}
+	for _, o := range s.Status.Overrides {
+		fmt.Fprintf(out, "  %s\n", describeOverride(o))
+	}
+
+	fmt.Fprintln(out, "\nConditions:")
+	if len(s.Status.Conditions) == 0 {
+		fmt.Fprintln(out, "  <none>")
+	} else {
+		w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tAGE\tMESSAGE")
+		for _, c := range s.Status.Conditions {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", c.Type, c.Status, c.Reason, age(c.LastTransitionTime), c.Message)
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintln(out, "\nHistory:")
+	return printHistory(out, s.Status.History, "  ")
+}
+
+func runHistory(client clientset.Interface, namespace string, args []string, out io.Writer) error {
+	s, err := getScaler(client, namespace, args)
+	if err != nil {
+		return err
+	}
+	return printHistory(out, s.Status.History, "")
+}
+
+func printHistory(out io.Writer, history []v1alpha1.ScalingDecision, indent string) error {
+	if len(history) == 0 {
+		fmt.Fprintf(out, "%s<none>\n", indent)
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "%sTIME\tFROM\tTO\tUTILIZATION\tRESULT\tREASON\n", indent)
+	for _, d := range history {
+		result := "Applied"
+		switch {
+		case d.Blocked:
+			result = "Blocked"
+		case d.DryRun:
+			result = "Recommended"
+		}
+		fmt.Fprintf(w, "%s%s\t%d\t%d\t%d%%\t%s\t%s\n", indent, d.Time.Format(time.RFC3339), d.FromReplicas,
+			d.ToReplicas, d.Utilization.Average, result, d.Reason)
+	}
+	return w.Flush()
+}
+
+func runPause(client clientset.Interface, namespace string, args []string, out io.Writer) error {
+	return annotate(client, namespace, args, out, v1alpha1.PausedAnnotation, "true", "paused")
+}
+
+func runResume(client clientset.Interface, namespace string, args []string, out io.Writer) error {
+	return annotate(client, namespace, args, out, v1alpha1.PausedAnnotation, "", "resumed")
+}
+
+func pinCommand(flags *pflag.FlagSet) runFunc {
+	replicas := flags.Int("replicas", -1, "Replicas to hold the target at")
+	period := flags.Duration("for", 0, "How long the pin lasts. It lasts until the target is unpinned when not set")
+	return func(client clientset.Interface, namespace string, args []string, out io.Writer) error {
+		return runPin(client, namespace, args, out, *replicas, *period)
+	}
+}
+
+func runPin(client clientset.Interface, namespace string, args []string, out io.Writer, replicas int,
+	period time.Duration) error {
+	if replicas < 0 {
+		return fmt.Errorf("--replicas is required")
+	}
+	if period < 0 {
+		return fmt.Errorf("--for must not be negative")
+	}
+	value := fmt.Sprintf("%d", replicas)
+	if period > 0 {
+		value += " until=" + now().Add(period).UTC().Format(time.RFC3339)
+	}
+	return annotate(client, namespace, args, out, v1alpha1.PinReplicasAnnotation, value, "pinned")
+}
+
+func runUnpin(client clientset.Interface, namespace string, args []string, out io.Writer) error {
+	return annotate(client, namespace, args, out, v1alpha1.PinReplicasAnnotation, "", "unpinned")
+}
+
+// annotate sets the annotation on the Scaler or removes it when the value is empty
+func annotate(client clientset.Interface, namespace string, args []string, out io.Writer, annotation, value,
+	action string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected the name of a Scaler")
+	}
+	var annotationValue interface{}
+	if value != "" {
+		annotationValue = value
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{annotation: annotationValue},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := client.ArjunnaikV1alpha1().Scalers(namespace).Patch(args[0], types.MergePatchType, patch); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "scaler.arjunnaik.in/%s %s\n", args[0], action)
+	return nil
+}
+
+func getScaler(client clientset.Interface, namespace string, args []string) (*v1alpha1.Scaler, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected the name of a Scaler")
+	}
+	return client.ArjunnaikV1alpha1().Scalers(namespace).Get(args[0], metav1.GetOptions{})
+}
+
+// scalerState summarizes what keeps the Scaler from scaling freely
+func scalerState(s *v1alpha1.Scaler) []string {
+	var state []string
+	for _, o := range s.Status.Overrides {
+		switch o.Type {
+		case v1alpha1.OverridePaused:
+			state = append(state, "Paused")
+		case v1alpha1.OverridePinReplicas:
+			if o.Replicas != nil {
+				state = append(state, fmt.Sprintf("Pinned(%d)", *o.Replicas))
+			}
+		}
+	}
+	for _, c := range s.Status.Conditions {
+		if c.Status == corev1.ConditionTrue {
+			state = append(state, string(c.Type))
+		}
+	}
+	if s.Spec.DryRun {
+		state = append(state, "DryRun")
+	}
+	if len(state) == 0 {
+		state = append(state, "Active")
+	}
+	return state
+}
+
+func describeOverride(o v1alpha1.ScalerOverride) string {
+	description := string(o.Type)
+	if o.Replicas != nil {
+		description += fmt.Sprintf(" %d", *o.Replicas)
+	}
+	if o.Until != nil {
+		description += fmt.Sprintf(" until %s", o.Until.UTC().Format(time.RFC3339))
+	}
+	return description
+}
+
+// lastUtilization returns the average utilization of the last decision and its age
+func lastUtilization(s *v1alpha1.Scaler) string {
+	if len(s.Status.History) == 0 {
+		return "<unknown>"
+	}
+	last := s.Status.History[len(s.Status.History)-1]
+	return fmt.Sprintf("%d%% (%s ago)", last.Utilization.Average, age(last.Time))
+}
+
+func age(t metav1.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.ShortHumanDuration(now().Sub(t.Time))
+}
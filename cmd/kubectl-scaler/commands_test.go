@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned/fake"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"testing"
+	"time"
+)
+
+func testScaler() *v1alpha1.Scaler {
+	created := metav1.NewTime(time.Date(2019, 1, 1, 15, 0, 0, 0, time.UTC))
+	pinned := int32(5)
+	return &v1alpha1.Scaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", CreationTimestamp: created},
+		Spec: v1alpha1.ScalerSpec{
+			MinReplicas: 1,
+			MaxReplicas: 10,
+			ScaleUp:     70,
+			ScaleDown:   20,
+			Evaluations: 5,
+			Target:      v1alpha1.ScaleTarget{Kind: "Deployment", Name: "web", APIVersion: "apps/v1"},
+		},
+		Status: v1alpha1.ScalerStatus{
+			CurrentReplicas: 3,
+			DesiredReplicas: 4,
+			Overrides:       []v1alpha1.ScalerOverride{{Type: v1alpha1.OverridePinReplicas, Replicas: &pinned}},
+			Conditions: []v1alpha1.ScalerCondition{
+				{Type: v1alpha1.ScalerFrozen, Status: corev1.ConditionTrue, Reason: "ScalingFrozen", Message: "release"},
+				{Type: v1alpha1.ScalerConflicting, Status: corev1.ConditionFalse},
+			},
+			History: []v1alpha1.ScalingDecision{
+				{Time: metav1.NewTime(time.Date(2019, 1, 2, 14, 50, 0, 0, time.UTC)), FromReplicas: 2, ToReplicas: 3,
+					Utilization: v1alpha1.UtilizationSummary{Average: 82}, Reason: "utilization above 70%"},
+				{Time: metav1.NewTime(time.Date(2019, 1, 2, 14, 55, 0, 0, time.UTC)), FromReplicas: 3, ToReplicas: 11,
+					Utilization: v1alpha1.UtilizationSummary{Average: 91}, Blocked: true, Reason: "above the maximum"},
+			},
+		},
+	}
+}
+
+func TestReadCommands(t *testing.T) {
+	now = func() time.Time { return time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	client := fake.NewSimpleClientset(testScaler())
+
+	t.Run("list", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		assert.NoError(t, runList(client, "default", false, out))
+		assert.Contains(t, out.String(), "Deployment/web")
+		assert.Contains(t, out.String(), "91% (5m ago)")
+		assert.Contains(t, out.String(), "Pinned(5),Frozen")
+		assert.Contains(t, out.String(), "1d")
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		assert.NoError(t, runDescribe(client, "default", []string{"web"}, out))
+		assert.Contains(t, out.String(), "current 3, desired 4, min 1, max 10")
+		assert.Contains(t, out.String(), "PinReplicas 5")
+		assert.Contains(t, out.String(), "ScalingFrozen")
+		assert.Contains(t, out.String(), "above the maximum")
+	})
+
+	t.Run("history", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		assert.NoError(t, runHistory(client, "default", []string{"web"}, out))
+		assert.Contains(t, out.String(), "Applied")
+		assert.Contains(t, out.String(), "Blocked")
+	})
+
+	t.Run("missing scaler", func(t *testing.T) {
+		assert.Error(t, runHistory(client, "default", []string{"api"}, &bytes.Buffer{}))
+	})
+}
+
+func TestWriteCommands(t *testing.T) {
+	now = func() time.Time { return time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	client := fake.NewSimpleClientset(testScaler())
+	annotations := func() map[string]string {
+		s, err := client.ArjunnaikV1alpha1().Scalers("default").Get("web", metav1.GetOptions{})
+		assert.NoError(t, err)
+		return s.Annotations
+	}
+	// the fake client keeps annotations which are removed, so the removal is checked in the patch
+	lastPatch := func() string {
+		actions := client.Actions()
+		patch, ok := actions[len(actions)-1].(clienttesting.PatchAction)
+		assert.True(t, ok)
+		return string(patch.GetPatch())
+	}
+	out := &bytes.Buffer{}
+
+	assert.NoError(t, runPause(client, "default", []string{"web"}, out))
+	assert.Equal(t, "true", annotations()[v1alpha1.PausedAnnotation])
+	assert.NoError(t, runResume(client, "default", []string{"web"}, out))
+	assert.Equal(t, `{"metadata":{"annotations":{"arjunnaik.in/paused":null}}}`, lastPatch())
+
+	assert.NoError(t, runPin(client, "default", []string{"web"}, out, 4, time.Hour))
+	assert.Equal(t, "4 until=2019-01-02T16:00:00Z", annotations()[v1alpha1.PinReplicasAnnotation])
+	assert.NoError(t, runPin(client, "default", []string{"web"}, out, 6, 0))
+	assert.Equal(t, "6", annotations()[v1alpha1.PinReplicasAnnotation])
+	assert.NoError(t, runUnpin(client, "default", []string{"web"}, out))
+	assert.Equal(t, `{"metadata":{"annotations":{"arjunnaik.in/pin-replicas":null}}}`, lastPatch())
+
+	assert.Error(t, runPin(client, "default", []string{"web"}, out, -1, 0))
+	assert.Error(t, runPause(client, "default", nil, out))
+	assert.Contains(t, out.String(), "scaler.arjunnaik.in/web paused")
+}
+
+func TestParseInterspersedFlags(t *testing.T) {
+	testCases := []struct {
+		name       string
+		command    string
+		args       []string
+		namespace  string
+		positional []string
+	}{
+		{name: "flags first", command: "describe", args: []string{"-n", "prod", "web"}, namespace: "prod",
+			positional: []string{"web"}},
+		{name: "flags last", command: "describe", args: []string{"web", "-n", "prod"}, namespace: "prod",
+			positional: []string{"web"}},
+		{name: "long flags", command: "pin", args: []string{"web", "--namespace=prod", "--replicas", "3", "--for", "1h"},
+			namespace: "prod", positional: []string{"web"}},
+		{name: "all namespaces", command: "list", args: []string{"-A"}, positional: []string{}},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			_, o, args, err := parse(c.command, commands[c.command], c.args, pflag.ContinueOnError)
+			assert.NoError(t, err)
+			assert.Equal(t, c.namespace, o.namespace)
+			assert.Equal(t, c.positional, args)
+		})
+	}
+}
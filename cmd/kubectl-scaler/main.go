@@ -0,0 +1,106 @@
+// kubectl-scaler is a kubectl plugin to inspect and operate Scalers. Install it anywhere in the
+// PATH and run `kubectl scaler <command>`.
+package main
+
+import (
+	"fmt"
+	clientset "github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned"
+	"github.com/spf13/pflag"
+	"io"
+	"k8s.io/client-go/tools/clientcmd"
+	"os"
+)
+
+const usage = `Inspect and operate Scalers.
+
+Usage:
+  kubectl scaler list [-n namespace | -A]
+  kubectl scaler describe <scaler> [-n namespace]
+  kubectl scaler history <scaler> [-n namespace]
+  kubectl scaler pause <scaler> [-n namespace]
+  kubectl scaler resume <scaler> [-n namespace]
+  kubectl scaler pin <scaler> --replicas N [--for 1h] [-n namespace]
+  kubectl scaler unpin <scaler> [-n namespace]
+
+Every command also accepts --kubeconfig and --context. Flags may come before or after the Scaler.
+`
+
+// runFunc runs a command against the Scalers in the namespace
+type runFunc func(client clientset.Interface, namespace string, args []string, out io.Writer) error
+
+// commands register their flags and return the function which runs them
+var commands = map[string]func(flags *pflag.FlagSet) runFunc{
+	"list":     listCommand,
+	"describe": noFlags(runDescribe),
+	"history":  noFlags(runHistory),
+	"pause":    noFlags(runPause),
+	"resume":   noFlags(runResume),
+	"pin":      pinCommand,
+	"unpin":    noFlags(runUnpin),
+}
+
+func noFlags(run runFunc) func(flags *pflag.FlagSet) runFunc {
+	return func(*pflag.FlagSet) runFunc { return run }
+}
+
+// options are the flags every command accepts
+type options struct {
+	kubeconfig string
+	context    string
+	namespace  string
+}
+
+// parse registers the flags of the command and parses the arguments. Like in kubectl the flags
+// may be interspersed with the positional arguments.
+func parse(command string, setup func(flags *pflag.FlagSet) runFunc, args []string,
+	errorHandling pflag.ErrorHandling) (runFunc, options, []string, error) {
+	var o options
+	flags := pflag.NewFlagSet("kubectl scaler "+command, errorHandling)
+	flags.SetInterspersed(true)
+	flags.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	flags.StringVar(&o.context, "context", "", "The kubeconfig context to use")
+	flags.StringVarP(&o.namespace, "namespace", "n", "", "Namespace of the Scalers. Defaults to the namespace of the context")
+	run := setup(flags)
+	err := flags.Parse(args)
+	return run, o, flags.Args(), err
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	setup, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	run, o, args, _ := parse(os.Args[1], setup, os.Args[2:], pflag.ExitOnError)
+	if err := execute(run, o.kubeconfig, o.context, o.namespace, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func execute(run runFunc, kubeconfig, context, namespace string, args []string) error {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules,
+		&clientcmd.ConfigOverrides{CurrentContext: context})
+	var err error
+	if namespace == "" {
+		if namespace, _, err = config.Namespace(); err != nil {
+			return fmt.Errorf("failed to determine the namespace: %v", err)
+		}
+	}
+	cfg, err := config.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %v", err)
+	}
+	client, err := clientset.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return run(client, namespace, args, os.Stdout)
+}
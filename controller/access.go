@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler"
+	log "github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"time"
+)
+
+const (
+	// permissionRetryInterval is how long to wait before checking the permissions again
+	// after the controller found that some were missing
+	permissionRetryInterval = time.Minute
+)
+
+// requiredPermissions are the permissions without which the controller cannot do any useful work
+var requiredPermissions = []authorizationv1.ResourceAttributes{
+	{Group: scaler.GroupName, Resource: "scalers", Verb: "list"},
+	{Group: scaler.GroupName, Resource: "scalers", Verb: "watch"},
+	{Group: scaler.GroupName, Resource: "scalers", Verb: "update"},
+	{Group: scaler.GroupName, Resource: "scalers", Subresource: "status", Verb: "update"},
+	{Group: "", Resource: "pods", Verb: "list"},
+	{Group: "", Resource: "pods", Verb: "watch"},
+	{Group: "", Resource: "events", Verb: "create"},
+	{Group: "", Resource: "secrets", Verb: "get"},
+	{Group: "autoscaling", Resource: "horizontalpodautoscalers", Verb: "list"},
+	{Group: "autoscaling", Resource: "horizontalpodautoscalers", Verb: "watch"},
+	{Group: "apps", Resource: "deployments", Verb: "list"},
+	{Group: "apps", Resource: "deployments", Verb: "watch"},
+	{Group: "apps", Resource: "statefulsets", Verb: "list"},
+	{Group: "apps", Resource: "statefulsets", Verb: "watch"},
+}
+
+// freezePermissions are required when the ScalingFreezes are watched. They are cluster scoped so
+// they are checked without a namespace even when the controller is restricted to one.
+var freezePermissions = []authorizationv1.ResourceAttributes{
+	{Group: scaler.GroupName, Resource: "scalingfreezes", Verb: "list"},
+	{Group: scaler.GroupName, Resource: "scalingfreezes", Verb: "watch"},
+}
+
+// shardPermissions are required in the namespace of the Leases when sharding is enabled
+var shardPermissions = []authorizationv1.ResourceAttributes{
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "get"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "list"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "create"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "update"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "delete"},
+}
+
+// WaitForPermissions blocks until the service account is allowed to perform all the required
+// actions in the namespace the controller is restricted to, and the additional actions of the
+// enabled features. It has to be called before the informers are started: a controller started
+// with a namespaced Role cannot list resources cluster wide, so instead of letting the informers
+// fail forever the missing permissions are logged and checked again periodically.
+func (c *Controller) WaitForPermissions(stopCh <-chan struct{}, additional ...authorizationv1.ResourceAttributes) error {
+	log.Info("Checking the controller permissions")
+	return wait.PollImmediateUntil(permissionRetryInterval, func() (bool, error) {
+		missing, err := c.missingPermissions(additional)
+		if err != nil {
+			// the check itself is best effort. If it cannot be performed let the informers try.
+			log.Warnf("failed to check the controller permissions: %v", err)
+			return true, nil
+		}
+		if len(missing) == 0 {
+			return true, nil
+		}
+		for _, m := range missing {
+			log.Errorf("missing permission: %s", m)
+		}
+		log.Errorf("waiting %s before checking the permissions again", permissionRetryInterval)
+		return false, nil
+	}, stopCh)
+}
+
+// missingPermissions checks the required permissions. The additional ones are checked in the
+// namespace of the controller unless they have a namespace of their own.
+func (c *Controller) missingPermissions(additional []authorizationv1.ResourceAttributes) ([]string, error) {
+	var checks []authorizationv1.ResourceAttributes
+	for _, attributes := range requiredPermissions {
+		attributes.Namespace = c.namespace
+		checks = append(checks, attributes)
+	}
+	if c.freezesLister != nil {
+		checks = append(checks, freezePermissions...)
+	}
+	if c.shards != nil {
+		for _, attributes := range shardPermissions {
+			attributes.Namespace = c.shards.Namespace()
+			checks = append(checks, attributes)
+		}
+	}
+	for _, attributes := range additional {
+		if attributes.Namespace == "" {
+			attributes.Namespace = c.namespace
+		}
+		checks = append(checks, attributes)
+	}
+
+	var missing []string
+	for i := range checks {
+		review, err := c.kubeclientset.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &checks[i]},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !review.Status.Allowed {
+			missing = append(missing, describePermission(checks[i]))
+		}
+	}
+	return missing, nil
+}
+
+func describePermission(attributes authorizationv1.ResourceAttributes) string {
+	resource := attributes.Resource
+	if attributes.Subresource != "" {
+		resource = fmt.Sprintf("%s/%s", resource, attributes.Subresource)
+	}
+	if attributes.Group != "" {
+		resource = fmt.Sprintf("%s.%s", resource, attributes.Group)
+	}
+	namespace := attributes.Namespace
+	if namespace == "" {
+		namespace = "all namespaces"
+	}
+	return fmt.Sprintf("%s %s in %s", attributes.Verb, resource, namespace)
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	listers "github.com/arjunrn/simple-scaler/pkg/client/listers/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/sharding"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"testing"
+	"time"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var checked []authorizationv1.ResourceAttributes
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := *review.Spec.ResourceAttributes
+		checked = append(checked, attributes)
+		// the Role only lacks the Leases and the permission to update Scalers
+		review.Status.Allowed = attributes.Resource != "leases" &&
+			!(attributes.Resource == "scalers" && attributes.Subresource == "" && attributes.Verb == "update")
+		return true, review, nil
+	})
+
+	c := &Controller{kubeclientset: client, namespace: "team-a",
+		shards: sharding.NewCoordinator(client, "kube-system", "scalers", "a", 15*time.Second)}
+	missing, err := c.missingPermissions([]authorizationv1.ResourceAttributes{
+		{Group: "arjunnaik.in", Resource: "scalingrecords", Verb: "create"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"update scalers.arjunnaik.in in team-a",
+		"get leases.coordination.k8s.io in kube-system",
+		"list leases.coordination.k8s.io in kube-system",
+		"create leases.coordination.k8s.io in kube-system",
+		"update leases.coordination.k8s.io in kube-system",
+		"delete leases.coordination.k8s.io in kube-system",
+	}, missing)
+
+	last := checked[len(checked)-1]
+	assert.Equal(t, "scalingrecords", last.Resource)
+	assert.Equal(t, "team-a", last.Namespace, "additional permissions are checked in the namespace of the controller")
+	var secretVerbs []string
+	for _, attributes := range checked {
+		if attributes.Resource == "secrets" {
+			secretVerbs = append(secretVerbs, attributes.Verb)
+		}
+		assert.NotEqual(t, "scalingfreezes", attributes.Resource, "the ScalingFreezes are not watched")
+	}
+	assert.Equal(t, []string{"get"}, secretVerbs, "the secrets are read one by one")
+
+	checked = nil
+	c.freezesLister = listers.NewScalingFreezeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+	_, err = c.missingPermissions(nil)
+	assert.NoError(t, err)
+	var freezeVerbs []string
+	for _, attributes := range checked {
+		if attributes.Resource == "scalingfreezes" {
+			freezeVerbs = append(freezeVerbs, attributes.Verb)
+			assert.Equal(t, "", attributes.Namespace, "ScalingFreezes are cluster scoped")
+		}
+	}
+	assert.Equal(t, []string{"list", "watch"}, freezeVerbs)
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+)
+
+const (
+	// ScalingRecordScalerLabel holds the name of the Scaler on the ScalingRecords it created
+	ScalingRecordScalerLabel = "arjunnaik.in/scaler"
+
+	ErrAudit = "ErrAudit"
+)
+
+// AuditSink keeps a durable record of the changes the controller makes to the replicas of the
+// targets
+type AuditSink interface {
+	Record(record *v1alpha1.ScalingRecord) error
+}
+
+// audit passes the record of a change to every audit sink. A failing sink is reported but does
+// not undo or stop the scaling.
+func (c *Controller) audit(scaler *v1alpha1.Scaler, entry v1alpha1.ScalingDecision, podMetrics map[string][]int) {
+	if len(c.options.AuditSinks) == 0 {
+		return
+	}
+	record := newScalingRecord(scaler, entry, podMetrics, c.options.Version)
+	for _, sink := range c.options.AuditSinks {
+		if err := sink.Record(record.DeepCopy()); err != nil {
+			log.Errorf("failed to audit scaling of %s by %s/%s: %v", describeTarget(scaler), scaler.Namespace,
+				scaler.Name, err)
+			c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrAudit, "failed to record the scaling of %s: %v",
+				describeTarget(scaler), err)
+		}
+	}
+}
+
+// newScalingRecord builds the audit record of a change to the target of the Scaler
+func newScalingRecord(scaler *v1alpha1.Scaler, entry v1alpha1.ScalingDecision, podMetrics map[string][]int,
+	version string) *v1alpha1.ScalingRecord {
+	pods := make([]string, 0, len(podMetrics))
+	for pod := range podMetrics {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+
+	metrics := make([]v1alpha1.PodMetrics, 0, len(pods))
+	for _, pod := range pods {
+		metrics = append(metrics, v1alpha1.PodMetrics{Pod: pod, Samples: podMetrics[pod]})
+	}
+
+	return &v1alpha1.ScalingRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: scaler.Name + "-",
+			Namespace:    scaler.Namespace,
+			Labels:       map[string]string{ScalingRecordScalerLabel: scaler.Name},
+		},
+		Spec: v1alpha1.ScalingRecordSpec{
+			Scaler:            scaler.Name,
+			Target:            scaler.Spec.Target,
+			Decision:          entry,
+			Metrics:           metrics,
+			ControllerVersion: version,
+		},
+	}
+}
+
+// replicaChange describes a change to the replicas which was not computed from the metrics,
+// like pinning the target or restoring it on delete
+func replicaChange(from, to int32, reason string) v1alpha1.ScalingDecision {
+	direction := v1alpha1.ScalingUp
+	if to < from {
+		direction = v1alpha1.ScalingDown
+	}
+	return v1alpha1.ScalingDecision{
+		Time:         metav1.Now(),
+		FromReplicas: from,
+		ToReplicas:   to,
+		Direction:    direction,
+		Reason:       reason,
+	}
+}
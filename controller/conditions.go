@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getCondition returns the condition of the given type or nil if the status does not have it
+func getCondition(status *v1alpha1.ScalerStatus, conditionType v1alpha1.ScalerConditionType) *v1alpha1.ScalerCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// isConditionTrue returns true if the status has the condition and it is true
+func isConditionTrue(status *v1alpha1.ScalerStatus, conditionType v1alpha1.ScalerConditionType) bool {
+	condition := getCondition(status, conditionType)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// setCondition sets the condition on the status. The transition time is only changed when
+// the status of the condition changes.
+func setCondition(status *v1alpha1.ScalerStatus, conditionType v1alpha1.ScalerConditionType,
+	conditionStatus corev1.ConditionStatus, reason, message string) {
+	condition := getCondition(status, conditionType)
+	if condition == nil {
+		status.Conditions = append(status.Conditions, v1alpha1.ScalerCondition{Type: conditionType})
+		condition = &status.Conditions[len(status.Conditions)-1]
+	}
+	if condition.Status != conditionStatus {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	condition.Status = conditionStatus
+	condition.Reason = reason
+	condition.Message = message
+}
+
+// conditionChanged returns true if setting the condition would change the status
+func conditionChanged(status *v1alpha1.ScalerStatus, conditionType v1alpha1.ScalerConditionType,
+	conditionStatus corev1.ConditionStatus, reason, message string) bool {
+	condition := getCondition(status, conditionType)
+	if condition == nil {
+		return conditionStatus == corev1.ConditionTrue
+	}
+	return condition.Status != conditionStatus || condition.Reason != reason || condition.Message != message
+}
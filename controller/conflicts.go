@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"sync"
+)
+
+const (
+	// targetIndex indexes Scalers and HorizontalPodAutoscalers by the workload they scale
+	targetIndex = "target"
+
+	ConflictingAutoscaler = "ConflictingAutoscaler"
+	NoConflict            = "NoConflict"
+)
+
+// targetKey identifies a scale target by namespace, group, kind and name. The version is left
+// out because the same workload can be referenced through different versions of its group.
+func targetKey(namespace, apiVersion, kind, name string) string {
+	group := apiVersion
+	if gv, err := schema.ParseGroupVersion(apiVersion); err == nil {
+		group = gv.Group
+	}
+	return groupTargetKey(namespace, group, kind, name)
+}
+
+func groupTargetKey(namespace, group, kind, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", namespace, group, kind, name)
+}
+
+func scalerTargetKey(scaler *v1alpha1.Scaler) string {
+	t := scaler.Spec.Target
+	return targetKey(scaler.Namespace, t.APIVersion, t.Kind, t.Name)
+}
+
+func scalerTargetIndexFunc(obj interface{}) ([]string, error) {
+	scaler, ok := obj.(*v1alpha1.Scaler)
+	if !ok {
+		return nil, fmt.Errorf("expected a Scaler but got %T", obj)
+	}
+	return []string{scalerTargetKey(scaler)}, nil
+}
+
+func hpaTargetIndexFunc(obj interface{}) ([]string, error) {
+	hpa, ok := obj.(*autoscalingv1.HorizontalPodAutoscaler)
+	if !ok {
+		return nil, fmt.Errorf("expected a HorizontalPodAutoscaler but got %T", obj)
+	}
+	ref := hpa.Spec.ScaleTargetRef
+	return []string{targetKey(hpa.Namespace, ref.APIVersion, ref.Kind, ref.Name)}, nil
+}
+
+// conflictWarnings remembers the newer HorizontalPodAutoscalers which were already warned about
+// an older Scaler so that a warning is not emitted on every resync
+type conflictWarnings struct {
+	mu     sync.Mutex
+	warned map[string]map[types.UID]bool
+}
+
+func newConflictWarnings() *conflictWarnings {
+	return &conflictWarnings{warned: make(map[string]map[types.UID]bool)}
+}
+
+// markWarned records the warning and returns true if it had not been recorded before
+func (w *conflictWarnings) markWarned(scalerKey string, uid types.UID) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.warned[scalerKey] == nil {
+		w.warned[scalerKey] = make(map[types.UID]bool)
+	}
+	if w.warned[scalerKey][uid] {
+		return false
+	}
+	w.warned[scalerKey][uid] = true
+	return true
+}
+
+func (w *conflictWarnings) forget(scalerKey string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.warned, scalerKey)
+}
+
+// autoscalersForTarget returns the other Scalers and the HorizontalPodAutoscalers which manage
+// the same target as the Scaler. Scalers in dry run mode do not change the target, so they are
+// left out.
+func (c *Controller) autoscalersForTarget(scaler *v1alpha1.Scaler) ([]runtime.Object, error) {
+	key := scalerTargetKey(scaler)
+	var result []runtime.Object
+
+	scalers, err := c.scalersIndexer.ByIndex(targetIndex, key)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range scalers {
+		other := o.(*v1alpha1.Scaler)
+		if other.UID == scaler.UID || other.DeletionTimestamp != nil || c.dryRun(other) {
+			continue
+		}
+		result = append(result, other)
+	}
+
+	hpas, err := c.hpasIndexer.ByIndex(targetIndex, key)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range hpas {
+		result = append(result, o.(*autoscalingv1.HorizontalPodAutoscaler))
+	}
+	return result, nil
+}
+
+// syncConflicts sets the Conflicting condition on the Scaler and returns true if an older
+// autoscaler manages the same target, in which case the Scaler must not act on it. A Scaler in
+// dry run mode never conflicts because it does not change the target, so it can be evaluated
+// next to an HPA which it feeds with external metrics.
+func (c *Controller) syncConflicts(scaler *v1alpha1.Scaler) (bool, *v1alpha1.Scaler, error) {
+	var others []runtime.Object
+	if !c.dryRun(scaler) {
+		var err error
+		if others, err = c.autoscalersForTarget(scaler); err != nil {
+			return false, scaler, err
+		}
+	}
+
+	var owner runtime.Object
+	for _, other := range others {
+		if !olderThan(other, scaler) {
+			// a newer Scaler reports the conflict itself. A newer HPA cannot be stopped, only warned.
+			if hpa, ok := other.(*autoscalingv1.HorizontalPodAutoscaler); ok &&
+				c.conflictWarnings.markWarned(scalerKey(scaler), hpa.UID) {
+				c.recorder.Eventf(hpa, corev1.EventTypeWarning, ConflictingAutoscaler,
+					"%s is already managed by Scaler %s", describeTarget(scaler), scaler.Name)
+			}
+			continue
+		}
+		if owner == nil || olderThan(other, owner) {
+			owner = other
+		}
+	}
+
+	if owner == nil {
+		if !isConditionTrue(&scaler.Status, v1alpha1.ScalerConflicting) {
+			return false, scaler, nil
+		}
+		log.Infof("conflict on the target of %s/%s has been resolved", scaler.Namespace, scaler.Name)
+		scaler, err := c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+			setCondition(status, v1alpha1.ScalerConflicting, corev1.ConditionFalse, NoConflict,
+				"no other autoscaler manages the target")
+		})
+		return false, scaler, err
+	}
+
+	message := fmt.Sprintf("%s is already managed by %s", describeTarget(scaler), describeObject(owner))
+	log.Infof("Scaler %s/%s conflicts: %s", scaler.Namespace, scaler.Name, message)
+	if !conditionChanged(&scaler.Status, v1alpha1.ScalerConflicting, corev1.ConditionTrue, ConflictingAutoscaler, message) {
+		return true, scaler, nil
+	}
+
+	c.recorder.Eventf(scaler, corev1.EventTypeWarning, ConflictingAutoscaler, "%s. not scaling", message)
+	c.recorder.Eventf(owner, corev1.EventTypeWarning, ConflictingAutoscaler, "%s is also targeted by Scaler %s",
+		describeTarget(scaler), scaler.Name)
+	scaler, err := c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		setCondition(status, v1alpha1.ScalerConflicting, corev1.ConditionTrue, ConflictingAutoscaler, message)
+	})
+	return true, scaler, err
+}
+
+// enqueueScalersForTarget queues all the Scalers which manage the target with the given key
+func (c *Controller) enqueueScalersForTarget(key string) {
+	scalers, err := c.scalersIndexer.ByIndex(targetIndex, key)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, s := range scalers {
+		c.enqueueScaler(s)
+	}
+}
+
+// enqueueScalersForHPA queues the Scalers which manage the same target as the HPA
+func (c *Controller) enqueueScalersForHPA(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	keys, err := hpaTargetIndexFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, key := range keys {
+		c.enqueueScalersForTarget(key)
+	}
+}
+
+// olderThan orders autoscalers by creation time and then by kind and name so that every
+// Scaler agrees on which autoscaler came first
+func olderThan(a, b runtime.Object) bool {
+	am, _ := meta.Accessor(a)
+	bm, _ := meta.Accessor(b)
+	at, bt := am.GetCreationTimestamp(), bm.GetCreationTimestamp()
+	if !at.Equal(&bt) {
+		return at.Before(&bt)
+	}
+	return describeObject(a) < describeObject(b)
+}
+
+func describeObject(obj runtime.Object) string {
+	m, _ := meta.Accessor(obj)
+	kind := "Scaler"
+	if _, ok := obj.(*autoscalingv1.HorizontalPodAutoscaler); ok {
+		kind = "HorizontalPodAutoscaler"
+	}
+	return fmt.Sprintf("%s %s/%s", kind, m.GetNamespace(), m.GetName())
+}
+
+func describeTarget(scaler *v1alpha1.Scaler) string {
+	return fmt.Sprintf("%s %s/%s", scaler.Spec.Target.Kind, scaler.Namespace, scaler.Spec.Target.Name)
+}
+
+func scalerKey(scaler *v1alpha1.Scaler) string {
+	return fmt.Sprintf("%s/%s", scaler.Namespace, scaler.Name)
+}
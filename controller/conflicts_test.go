@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"testing"
+	"time"
+)
+
+func TestSyncConflicts(t *testing.T) {
+	created := metav1.NewTime(time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC))
+	target := v1alpha1.ScaleTarget{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}
+	hpa := &autoscalingv1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", UID: "hpa", CreationTimestamp: created},
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+	}
+	newScaler := func(name string, dryRun bool) *v1alpha1.Scaler {
+		return &v1alpha1.Scaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID("scaler-" + name),
+				CreationTimestamp: metav1.NewTime(created.Add(time.Hour))},
+			Spec: v1alpha1.ScalerSpec{Target: target, DryRun: dryRun},
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		dryRun      bool
+		conflicting bool
+	}{
+		{name: "newer than the HPA", conflicting: true},
+		{name: "in dry run mode", dryRun: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaler := newScaler("web", tc.dryRun)
+			// the Scaler was marked as conflicting before it was switched to dry run mode
+			setCondition(&scaler.Status, v1alpha1.ScalerConflicting, corev1.ConditionTrue, ConflictingAutoscaler,
+				"Deployment default/web is already managed by HorizontalPodAutoscaler default/web")
+			c := newConflictController(scaler)
+			assert.NoError(t, c.hpasIndexer.Add(hpa))
+
+			conflicting, updated, err := c.syncConflicts(scaler)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.conflicting, conflicting)
+			assert.Equal(t, tc.conflicting, isConditionTrue(&updated.Status, v1alpha1.ScalerConflicting))
+		})
+	}
+
+	t.Run("newer than a Scaler in dry run mode", func(t *testing.T) {
+		older, scaler := newScaler("shadow", true), newScaler("web", false)
+		older.CreationTimestamp = created
+		c := newConflictController(older, scaler)
+
+		conflicting, _, err := c.syncConflicts(scaler)
+		assert.NoError(t, err)
+		assert.False(t, conflicting)
+	})
+}
+
+func newConflictController(scalers ...*v1alpha1.Scaler) *Controller {
+	c := &Controller{
+		scalerclientset:  fake.NewSimpleClientset(),
+		recorder:         record.NewFakeRecorder(10),
+		conflictWarnings: newConflictWarnings(),
+		scalersIndexer:   cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{targetIndex: scalerTargetIndexFunc}),
+		hpasIndexer:      cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{targetIndex: hpaTargetIndexFunc}),
+	}
+	for _, s := range scalers {
+		c.scalersIndexer.Add(s)
+		c.scalerclientset.ArjunnaikV1alpha1().Scalers(s.Namespace).Create(s)
+	}
+	return c
+}
@@ -6,7 +6,10 @@ import (
 	clientset "github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned"
 	scalescheme "github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned/scheme"
 	informers "github.com/arjunrn/simple-scaler/pkg/client/informers/externalversions/scaler/v1alpha1"
+	listers "github.com/arjunrn/simple-scaler/pkg/client/listers/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/notify"
 	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	"github.com/arjunrn/simple-scaler/pkg/sharding"
 	prometheus "github.com/prometheus/client_golang/api"
 	log "github.com/sirupsen/logrus"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
@@ -19,13 +22,17 @@ import (
 	"k8s.io/apimachinery/pkg/util/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	autoscalinginformers "k8s.io/client-go/informers/autoscaling/v1"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	scaleclient "k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"time"
 )
@@ -34,56 +41,200 @@ const (
 	controllerAgentName = "scaler-controller"
 	ErrComputeMetrics   = "ErrComputeMetrics"
 	ErrUpdateTarget     = "ErrUpdateTarget"
+	ErrForbidden        = "ErrForbidden"
 	TargetUpdateSuccess = "TargetUpdateSuccess"
 )
 
+// Options are the settings which apply to all the Scalers handled by the controller
+type Options struct {
+	// DriftGracePeriod is how long a manual change to the replicas of a target is respected
+	DriftGracePeriod time.Duration
+	// DryRun stops the controller from changing any target, as if every Scaler had spec.dryRun set
+	DryRun bool
+	// HistoryLength is the number of decisions kept in the status of every Scaler. Zero disables
+	// the history.
+	HistoryLength int
+	// AuditSinks receive a record of every change to the replicas of a target
+	AuditSinks []AuditSink
+	// Version of the controller which is written to the audit records
+	Version string
+	// Notifier delivers the notifications configured on the Scalers. No notifications are sent
+	// when it is nil.
+	Notifier *notify.Notifier
+	// Publisher emits the decisions to an event bus when it is set
+	Publisher Publisher
+	// MetricsSource provides the utilization of the pods. Defaults to Prometheus.
+	MetricsSource replicacalculator.MetricsSource
+}
+
 // Controller is the controller implementation for Foo resources
 type Controller struct {
+	// namespace the controller is restricted to. Empty when all namespaces are watched.
+	namespace string
 	// kubeclientset is a standard kubernetes clientset
 	kubeclientset kubernetes.Interface
 	// scalerclientset is a clientset for our own API group
 	scalerclientset clientset.Interface
 
-	queue            workqueue.RateLimitingInterface
-	scalersSynced    cache.InformerSynced
-	mapper           apimeta.RESTMapper
-	scaleNamespacer  scaleclient.ScalesGetter
-	replicaCalc      *replicacalculator.ReplicaCalculator
+	queue          workqueue.RateLimitingInterface
+	scalersLister  listers.ScalerLister
+	scalersIndexer cache.Indexer
+	scalersSynced  cache.InformerSynced
+	hpasIndexer    cache.Indexer
+	hpasSynced     cache.InformerSynced
+	// deployments and statefulsets are watched to suspend scaling while they roll out
+	deploymentsLister  appslisters.DeploymentLister
+	deploymentsSynced  cache.InformerSynced
+	statefulSetsLister appslisters.StatefulSetLister
+	statefulSetsSynced cache.InformerSynced
+	// ScalingFreezes are cluster wide and can block scaling of any Scaler. The lister is nil when
+	// they are not watched.
+	freezesLister listers.ScalingFreezeLister
+	freezesSynced cache.InformerSynced
+	// secrets hold the keys which sign the notifications
+	secrets         *secretCache
+	mapper          apimeta.RESTMapper
+	scaleNamespacer scaleclient.ScalesGetter
+	podLister       replicacalculator.PodLister
+	replicaCalc     *replicacalculator.ReplicaCalculator
+	// activation runs the activation queries of Scalers which scale to zero. It is nil when
+	// the metrics source does not support them.
+	activation replicacalculator.ActivationSource
+	// demand provides the history for the forecasts of predictive Scalers. It is nil when the
+	// metrics source does not support it.
+	demand           replicacalculator.DemandSource
 	prometheusClient prometheus.Client
 	recorder         record.EventRecorder
+	// shards is nil unless sharding is enabled. When set only the Scalers owned by this
+	// replica are added to the queue.
+	shards *sharding.Coordinator
+	// cleanups are called with the key of a deleted Scaler to drop the state kept for it
+	cleanups         []func(key string)
+	conflictWarnings *conflictWarnings
+	notifications    *notificationState
+	forecasts        *forecasts
+	evaluations      *evaluations
+	externalSources  *externalSources
+	reported         *reported
+	options          Options
 }
 
-// NewController returns a new sample controller
+// NewController returns a new sample controller. The freeze informer is nil when the ScalingFreezes
+// are not watched.
 func NewController(kubeclientset kubernetes.Interface, scalerclientset clientset.Interface,
 	scalerInformer informers.ScalerInformer, podInformer coreinformers.PodInformer,
+	hpaInformer autoscalinginformers.HorizontalPodAutoscalerInformer, deploymentInformer appsinformers.DeploymentInformer,
+	statefulSetInformer appsinformers.StatefulSetInformer, freezeInformer informers.ScalingFreezeInformer,
 	scaleNamespacer scaleclient.ScalesGetter, mapper apimeta.RESTMapper, prometheusClient prometheus.Client,
-	resyncInterval time.Duration) *Controller {
+	shards *sharding.Coordinator, namespace string, resyncInterval time.Duration, options Options) *Controller {
 
 	utilruntime.Must(scalescheme.AddToScheme(scheme.Scheme))
 	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events(namespace)})
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 	controller := &Controller{
-		kubeclientset:    kubeclientset,
-		scalerclientset:  scalerclientset,
-		queue:            workqueue.NewNamedRateLimitingQueue(NewDefaultScalerRateLimiter(resyncInterval), "scalers"),
-		scalersSynced:    scalerInformer.Informer().HasSynced,
-		scaleNamespacer:  scaleNamespacer,
-		prometheusClient: prometheusClient,
-		recorder:         recorder,
+		namespace:          namespace,
+		kubeclientset:      kubeclientset,
+		scalerclientset:    scalerclientset,
+		queue:              workqueue.NewNamedRateLimitingQueue(NewDefaultScalerRateLimiter(resyncInterval), "scalers"),
+		scalersLister:      scalerInformer.Lister(),
+		scalersIndexer:     scalerInformer.Informer().GetIndexer(),
+		scalersSynced:      scalerInformer.Informer().HasSynced,
+		hpasIndexer:        hpaInformer.Informer().GetIndexer(),
+		hpasSynced:         hpaInformer.Informer().HasSynced,
+		deploymentsLister:  deploymentInformer.Lister(),
+		deploymentsSynced:  deploymentInformer.Informer().HasSynced,
+		statefulSetsLister: statefulSetInformer.Lister(),
+		statefulSetsSynced: statefulSetInformer.Informer().HasSynced,
+		secrets:            newSecretCache(kubeclientset),
+		scaleNamespacer:    scaleNamespacer,
+		prometheusClient:   prometheusClient,
+		recorder:           recorder,
+		shards:             shards,
+		conflictWarnings:   newConflictWarnings(),
+		notifications:      newNotificationState(),
+		forecasts:          newForecasts(),
+		evaluations:        newEvaluations(),
+		externalSources:    newExternalSources(),
+		reported:           newReported(),
+		options:            options,
 	}
+	controller.cleanups = append(controller.cleanups, controller.conflictWarnings.forget, controller.notifications.forget,
+		controller.forecasts.forget, controller.evaluations.forget, controller.externalSources.forget, controller.reported.forget)
 	controller.mapper = mapper
-	podLister := podInformer.Lister()
-
-	metricsSource := replicacalculator.NewPrometheusMetricsSource(prometheusClient)
+	err := podInformer.Informer().AddIndexers(cache.Indexers{
+		replicacalculator.PodLabelIndex: replicacalculator.PodLabelIndexFunc,
+	})
+	utilruntime.Must(err)
+	err = scalerInformer.Informer().AddIndexers(cache.Indexers{targetIndex: scalerTargetIndexFunc})
+	utilruntime.Must(err)
+	err = hpaInformer.Informer().AddIndexers(cache.Indexers{targetIndex: hpaTargetIndexFunc})
+	utilruntime.Must(err)
+	podLister := replicacalculator.NewIndexedPodLister(podInformer.Informer().GetIndexer())
+	controller.podLister = podLister
+
+	metricsSource := options.MetricsSource
+	if metricsSource == nil {
+		metricsSource = replicacalculator.NewPrometheusMetricsSource(prometheusClient)
+	}
 	controller.replicaCalc = replicacalculator.NewReplicaCalculator(podLister, metricsSource)
+	if activation, ok := metricsSource.(replicacalculator.ActivationSource); ok {
+		controller.activation = activation
+	}
+	if demand, ok := metricsSource.(replicacalculator.DemandSource); ok {
+		controller.demand = demand
+	}
 	log.Info("Setting up event handlers")
 	scalerInformer.Informer().AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc: controller.enqueueScaler,
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			controller.enqueueScaler(newObj)
+			// Scalers in dry run mode are ignored by the conflict checks of the others
+			if old, scaler := oldObj.(*v1alpha1.Scaler), newObj.(*v1alpha1.Scaler); old.Spec.DryRun != scaler.Spec.DryRun {
+				controller.enqueueScalersForTarget(scalerTargetKey(scaler))
+			}
 		},
+		DeleteFunc: controller.deleteScaler,
 	}, resyncInterval)
+	hpaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueScalersForHPA,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			controller.enqueueScalersForHPA(oldObj)
+			controller.enqueueScalersForHPA(newObj)
+		},
+		DeleteFunc: controller.enqueueScalersForHPA,
+	})
+	for kind, informer := range map[string]cache.SharedIndexInformer{
+		"Deployment":  deploymentInformer.Informer(),
+		"StatefulSet": statefulSetInformer.Informer(),
+	} {
+		handler := controller.enqueueScalersForWorkload(kind)
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: handler,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				handler(newObj)
+			},
+			DeleteFunc: handler,
+		})
+	}
+
+	// the ScalingFreezes are optional since a controller with a namespaced Role cannot watch them
+	if freezeInformer != nil {
+		controller.freezesLister = freezeInformer.Lister()
+		controller.freezesSynced = freezeInformer.Informer().HasSynced
+		freezeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: controller.enqueueScalersForFreeze,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				controller.enqueueScalersForFreeze(newObj)
+			},
+			DeleteFunc: controller.enqueueScalersForFreeze,
+		})
+	}
+
+	if shards != nil {
+		// Scalers which moved to this replica would otherwise wait for the next resync
+		shards.AddMembershipHandler(controller.enqueueAllScalers)
+	}
 
 	return controller
 }
@@ -100,10 +251,22 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	log.Info("Starting Scaler controller")
 
 	log.Info("Waiting for informer caches to be synced")
-	if ok := cache.WaitForCacheSync(stopCh, c.scalersSynced); !ok {
+	synced := []cache.InformerSynced{c.scalersSynced, c.hpasSynced, c.deploymentsSynced, c.statefulSetsSynced}
+	if c.freezesSynced != nil {
+		synced = append(synced, c.freezesSynced)
+	}
+	if ok := cache.WaitForCacheSync(stopCh, synced...); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
+	if c.shards != nil {
+		log.Info("Waiting for shard membership to be synced")
+		if ok := cache.WaitForCacheSync(stopCh, c.shards.HasSynced); !ok {
+			return fmt.Errorf("failed to wait for shard membership to sync")
+		}
+		c.enqueueAllScalers()
+	}
+
 	log.Info("starting workers")
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(c.runWorker, time.Second, stopCh)
@@ -146,12 +309,24 @@ func (c *Controller) reconcileKey(key string) error {
 		return err
 	}
 
-	scaler, err := c.scalerclientset.ArjunnaikV1alpha1().Scalers(namespace).Get(name, metav1.GetOptions{})
+	// the key could have been queued before the ownership moved to another replica
+	if c.shards != nil && !c.shards.Owns(key) {
+		log.Debugf("Scaler %s is owned by another shard", key)
+		return nil
+	}
+
+	scaler, err := c.scalersLister.Scalers(namespace).Get(name)
 	if errors.IsNotFound(err) {
-		log.Errorf("Scaler %s has been deleted", name)
+		log.Infof("Scaler %s has been deleted", key)
+		c.forgetScaler(key)
 		return nil
 	}
-	return c.reconcileScaler(scaler)
+	if err != nil {
+		return err
+	}
+	err = c.reconcileScaler(scaler)
+	c.trackFailures(scaler, err)
+	return err
 }
 
 func (c *Controller) enqueueScaler(obj interface{}) {
@@ -161,97 +336,206 @@ func (c *Controller) enqueueScaler(obj interface{}) {
 		runtime.HandleError(err)
 		return
 	}
+	if c.shards != nil && !c.shards.Owns(key) {
+		return
+	}
 	c.queue.AddRateLimited(key)
 }
 
+func (c *Controller) enqueueAllScalers() {
+	scalers, err := c.scalersLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, s := range scalers {
+		c.enqueueScaler(s)
+	}
+}
+
 func (c *Controller) reconcileScaler(scalerShared *v1alpha1.Scaler) error {
 	log.Infof("now processing scaler: %s", scalerShared.Name)
 	scaler := scalerShared.DeepCopy()
-	version, err := schema.ParseGroupVersion(scaler.Spec.Target.APIVersion)
+
+	if scaler.DeletionTimestamp != nil {
+		return c.finalizeScaler(scaler)
+	}
+
+	scaler, err := c.syncFinalizer(scaler)
 	if err != nil {
 		return err
 	}
-	targetGK := schema.GroupKind{
-		Group: version.Group,
-		Kind:  scaler.Spec.Target.Kind,
-	}
-	mappings, err := c.mapper.RESTMappings(targetGK)
+
+	disabled, err := isDisabled(scaler)
+	invalidDisabled := ""
 	if err != nil {
+		invalidDisabled = err.Error()
+	}
+	if c.reported.changed(scalerKey(scaler), v1alpha1.DisabledAnnotation, invalidDisabled) && err != nil {
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrInvalidOverride, "ignoring annotation %s: %v",
+			v1alpha1.DisabledAnnotation, err)
+	}
+	if disabled {
+		log.Infof("autoscaling of %s/%s is disabled", scaler.Namespace, scaler.Name)
+		return nil
+	}
+
+	conflicting, scaler, err := c.syncConflicts(scaler)
+	if err != nil || conflicting {
 		return err
 	}
-	log.Debugf("Found mappings: %v", mappings)
-	scale, targetGR, err := c.scaleForResourceMappings(scaler.Namespace, scaler.Spec.Target.Name, mappings)
+
+	activeOverrides, scaler, err := c.syncOverrides(scaler)
 	if err != nil {
 		return err
 	}
-	log.Debugf("Found scale: %v target group: %v", scale.Name, targetGR.Resource)
+	if activeOverrides.paused {
+		log.Infof("scaler %s/%s is paused", scaler.Namespace, scaler.Name)
+		return nil
+	}
 
-	currentReplicas := scale.Status.Replicas
-	desiredReplicas := int32(0)
+	activeOverrides.schedule, scaler, err = c.syncSchedules(scaler)
+	if err != nil {
+		return err
+	}
 
-	if scale.Spec.Replicas == 0 {
-		log.Infof("autoscaling disabled by target. %v", scale)
-		return nil
+	freezes, scaler, err := c.syncFreezes(scaler)
+	if err != nil {
+		return err
 	}
 
-	replicas, err := c.computeReplicasForMetrics(scaler, scale)
+	rollingOut, scaler, err := c.syncRollout(scaler)
+	if err != nil || rollingOut {
+		return err
+	}
 
-	if err == nil {
-		desiredReplicas = replicas
-	} else {
-		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrComputeMetrics, "failed to compute replicas: %v", err)
+	scale, targetGR, err := c.targetScale(scaler)
+	if errors.IsForbidden(err) {
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrForbidden, "not allowed to get the scale of %s/%s: %v",
+			scaler.Namespace, scaler.Spec.Target.Name, err)
 		return err
 	}
+	if err != nil {
+		return err
+	}
+	log.Debugf("Found scale: %v target group: %v", scale.Name, targetGR.Resource)
 
-	log.Infof("target: %s currentReplicas: %d desiredReplicas: %d", scaler.Name, currentReplicas, desiredReplicas)
+	if scaler.Status.OriginalReplicas == nil {
+		originalReplicas := scale.Spec.Replicas
+		scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+			if status.OriginalReplicas == nil {
+				status.OriginalReplicas = &originalReplicas
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-	if desiredReplicas < scaler.Spec.MinReplicas {
-		log.Infof("cannot scaled down more than min replicas")
-		return nil
+	// a pin applies to a target without replicas too
+	if activeOverrides.pin != nil {
+		return c.applyPin(scaler, scale, targetGR, *activeOverrides.pin, freezes)
 	}
 
-	if desiredReplicas > scaler.Spec.MaxReplicas {
-		log.Infof("cannot scale up more than max replicas")
+	if scale.Spec.Replicas == 0 && scaler.Spec.ScaleToZero == nil {
+		log.Infof("target of %s/%s has no replicas and the Scaler cannot scale from zero", scaler.Namespace, scaler.Name)
 		return nil
 	}
 
-	if desiredReplicas == scale.Spec.Replicas {
-		log.Infof("the current replicas and required replicas are the same")
-		return nil
+	overridden, scaler, err := c.syncDrift(scaler, scale)
+	if err != nil || overridden {
+		return err
 	}
 
-	lastUpdated, err := time.Parse(time.RFC3339, scaler.Status.LastScalingTimestamp)
+	active, scaler, err := c.syncActivation(scaler)
 	if err != nil {
-		log.Debugf("failed to find last updated time")
-		lastUpdated = time.Now().Add(-time.Minute * 5)
+		return err
+	}
+
+	forecast, scaler, err := c.syncForecast(scaler, scale, activeOverrides)
+	if err != nil {
+		return err
+	}
+
+	d, err := c.decide(scaler, scale, activeOverrides, active)
+	if err != nil {
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrComputeMetrics, "failed to compute replicas: %v", err)
+		c.publish(EventMetricsFailed, scaler, metricsFailure(scale.Spec.Replicas, err))
+		return err
+	}
+	d = applyForecast(d, scaler, forecast)
+	d = c.suppressFrozen(scaler, d, freezes)
+	c.evaluations.set(scalerKey(scaler), d.evaluation(time.Now()))
+
+	log.Infof("target: %s currentReplicas: %d desiredReplicas: %d", scaler.Name, scale.Status.Replicas, d.desiredReplicas)
+
+	if c.dryRun(scaler) {
+		return c.recommend(scaler, scale, d)
 	}
 
-	if lastUpdated.Add(time.Minute * 1).After(time.Now()) {
-		log.Infof("still in cooldown period since last scaling period")
+	if !d.scale() {
+		log.Info(d.reason)
+		c.notifyDecision(scaler, d)
+		if d.blocked {
+			return c.recordBlocked(scaler, d)
+		}
+		c.reported.changed(scalerKey(scaler), EventBlocked, "")
 		return nil
 	}
+	c.reported.changed(scalerKey(scaler), EventBlocked, "")
 
-	scale.Spec.Replicas = desiredReplicas
-	_, err = c.scaleNamespacer.Scales(scale.Namespace).Update(targetGR, scale)
+	desiredReplicas := d.desiredReplicas
+	entry := d.record(false)
+	scaler, err = c.updateTargetReplicas(scaler, scale, targetGR, desiredReplicas)
 
+	if errors.IsForbidden(err) {
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrForbidden, "not allowed to update the scale of %s/%s: %v",
+			scale.Namespace, scale.Name, err)
+		return err
+	}
 	if err != nil {
 		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrUpdateTarget, "failed to update target %s/%s",
 			scale.Namespace, scale.Name)
 		return err
 	}
 
-	scaler.Status.Condition = fmt.Sprintf("Scaled to %d replicas", desiredReplicas)
-	scaler.Status.LastScalingTimestamp = time.Now().Format(time.RFC3339)
-	scaler.Status.CurrentReplicas = desiredReplicas
-	_, err = c.scalerclientset.ArjunnaikV1alpha1().Scalers(scaler.Namespace).UpdateStatus(scaler)
+	_, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		status.Condition = fmt.Sprintf("Scaled to %d replicas", desiredReplicas)
+		status.LastScalingTimestamp = time.Now().Format(time.RFC3339)
+		status.CurrentReplicas = desiredReplicas
+		status.DesiredReplicas = desiredReplicas
+		status.Reason = d.reason
+		c.recordDecision(status, entry)
+	})
 	if err != nil {
 		log.Errorf("Failed to Update Scaler Status %v", err)
 	}
+	c.audit(scaler, entry, d.podMetrics)
+	c.publish(EventScaled, scaler, entry)
+	c.notifyDecision(scaler, d)
 	c.recorder.Eventf(scaler, corev1.EventTypeNormal, TargetUpdateSuccess,
 		"successfully updated target %s/%s with replicas %d", scale.Namespace, scale.Name, desiredReplicas)
 	return nil
 }
 
+// targetScale returns the scale subresource of the target of the Scaler
+func (c *Controller) targetScale(scaler *v1alpha1.Scaler) (*autoscalingv1.Scale, schema.GroupResource, error) {
+	version, err := schema.ParseGroupVersion(scaler.Spec.Target.APIVersion)
+	if err != nil {
+		return nil, schema.GroupResource{}, err
+	}
+	targetGK := schema.GroupKind{
+		Group: version.Group,
+		Kind:  scaler.Spec.Target.Kind,
+	}
+	mappings, err := c.mapper.RESTMappings(targetGK)
+	if err != nil {
+		return nil, schema.GroupResource{}, err
+	}
+	log.Debugf("Found mappings: %v", mappings)
+	return c.scaleForResourceMappings(scaler.Namespace, scaler.Spec.Target.Name, mappings)
+}
+
 func (c *Controller) scaleForResourceMappings(namespace, name string, mappings []*apimeta.RESTMapping) (*autoscalingv1.Scale, schema.GroupResource, error) {
 	var firstErr error
 	for i, mapping := range mappings {
@@ -276,29 +560,50 @@ func (c *Controller) scaleForResourceMappings(namespace, name string, mappings [
 	return nil, schema.GroupResource{}, firstErr
 
 }
-func (c *Controller) computeReplicasForMetrics(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale, ) (replicas int32, err error) {
+func (c *Controller) computeReplicasForMetrics(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale,
+	scaleUp, scaleDown int32) (*replicacalculator.Recommendation, error) {
 	currentReplicas := scale.Status.Replicas
 
 	if scale.Status.Selector == "" {
 		log.Errorf("Target needs a selector: %v", scale)
-		return 0, fmt.Errorf("selector required")
+		return nil, fmt.Errorf("selector required")
 	}
 
 	selector, err := labels.Parse(scale.Status.Selector)
 	if err != nil {
-		return -1, fmt.Errorf("couldn't convert selector into a corresponding internal selector object: %v", err)
+		return nil, fmt.Errorf("couldn't convert selector into a corresponding internal selector object: %v", err)
 	}
 
-	replicaCountProposal, err := c.replicaCalc.GetResourceReplicas(scaler.Namespace, scaler.Spec.Evaluations,
-		currentReplicas, scaler.Spec.ScaleDown, scaler.Spec.ScaleUp, scaler.Spec.ScaleUpSize,
-		scaler.Spec.ScaleDownSize, selector)
+	calculator, err := c.replicaCalculator(scaler)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	return replicaCountProposal, nil
+	return calculator.GetRecommendation(scaler.Namespace, scaler.Spec.Evaluations,
+		currentReplicas, scaleDown, scaleUp, scaler.Spec.ScaleUpSize,
+		scaler.Spec.ScaleDownSize, selector)
 }
 
-func (c *Controller) updateStatus(scaler *v1alpha1.Scaler) error {
-
-	return nil
+// updateStatus applies the mutation to the status of the Scaler and writes it. The copy from
+// the informer cache can be out of date, so on a conflict the latest version is fetched from
+// the API server and the mutation is applied to it again. Otherwise the scaling timestamp
+// would be lost and the cooldown would not be honored.
+func (c *Controller) updateStatus(scaler *v1alpha1.Scaler, mutate func(status *v1alpha1.ScalerStatus)) (*v1alpha1.Scaler, error) {
+	scalers := c.scalerclientset.ArjunnaikV1alpha1().Scalers(scaler.Namespace)
+	latest := scaler.DeepCopy()
+	var updated *v1alpha1.Scaler
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mutate(&latest.Status)
+		var err error
+		updated, err = scalers.UpdateStatus(latest)
+		if !errors.IsConflict(err) {
+			return err
+		}
+		fresh, getErr := scalers.Get(scaler.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		latest = fresh
+		return err
+	})
+	return updated, err
 }
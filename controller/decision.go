@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	log "github.com/sirupsen/logrus"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+const (
+	ScalingRecommended = "ScalingRecommended"
+
+	// scalingCooldown is the minimum time between two scaling actions on the same target
+	scalingCooldown = time.Minute
+)
+
+// decision is the outcome of evaluating a Scaler against the metrics of its target
+type decision struct {
+	// currentReplicas are the replicas the target is set to
+	currentReplicas int32
+	// desiredReplicas are the replicas proposed by the replica calculator
+	desiredReplicas int32
+	// blocked is true when the proposal differs from the current replicas but may not be applied
+	blocked bool
+	// atBound is true when the proposal was blocked by the minimum or the maximum replicas
+	atBound bool
+	// cooldown is true when the proposal was blocked because the target was scaled too recently
+	cooldown bool
+	reason   string
+	// utilization summarizes the metrics the proposal was based on
+	utilization replicacalculator.Utilization
+	// podMetrics are the samples of every pod
+	podMetrics map[string][]int
+	// minReplicas and maxReplicas are the bounds including overrides
+	minReplicas int32
+	maxReplicas int32
+	// scaleUpThreshold and scaleDownThreshold are the utilization thresholds which applied
+	scaleUpThreshold   int32
+	scaleDownThreshold int32
+}
+
+// scale returns true if the target should be set to the desired replicas
+func (d decision) scale() bool {
+	return !d.blocked && d.desiredReplicas != d.currentReplicas
+}
+
+// decide computes the desired replicas for the target and checks them against the bounds and
+// the cooldown. It does not change anything.
+func (c *Controller) decide(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale, o overrides, a activation) (decision, error) {
+	d := decision{
+		currentReplicas:    scale.Spec.Replicas,
+		minReplicas:        o.minReplicas(scaler),
+		maxReplicas:        o.maxReplicas(scaler),
+		scaleUpThreshold:   o.scaleUpThreshold(scaler),
+		scaleDownThreshold: o.scaleDownThreshold(scaler),
+	}
+
+	if scale.Spec.Replicas == 0 && a.enabled {
+		return applyCooldown(decideFromZero(d, scaler, a), scaler, time.Now()), nil
+	}
+	if a.idle && d.minReplicas == 0 {
+		d.desiredReplicas = 0
+		d.reason = fmt.Sprintf("not in use for %d seconds", scaler.Spec.ScaleToZero.IdleSeconds)
+		return applyCooldown(d, scaler, time.Now()), nil
+	}
+
+	recommendation, err := c.computeReplicasForMetrics(scaler, scale, d.scaleUpThreshold, d.scaleDownThreshold)
+	if err != nil {
+		return d, err
+	}
+	replicas := recommendation.Replicas
+	d.desiredReplicas = replicas
+	d.utilization = recommendation.Utilization
+	d.podMetrics = recommendation.PodMetrics
+
+	if replicas < d.minReplicas {
+		d.blocked = true
+		d.atBound = true
+		d.reason = fmt.Sprintf("cannot scale down to %d replicas, below the minimum of %d", replicas, d.minReplicas)
+		return d, nil
+	}
+
+	if replicas < 1 {
+		// only the activation query can tell whether a target is idle
+		d.blocked = true
+		d.reason = "cannot scale down to 0 replicas before the target is idle"
+		return d, nil
+	}
+
+	if replicas > d.maxReplicas {
+		d.blocked = true
+		d.atBound = true
+		d.reason = fmt.Sprintf("cannot scale up to %d replicas, above the maximum of %d", replicas, d.maxReplicas)
+		return d, nil
+	}
+
+	if replicas == scale.Spec.Replicas {
+		d.reason = "the current replicas and required replicas are the same"
+		return d, nil
+	}
+
+	if d = applyCooldown(d, scaler, time.Now()); d.blocked {
+		return d, nil
+	}
+
+	if replicas > scale.Spec.Replicas {
+		d.reason = fmt.Sprintf("utilization above %d%% for %d evaluations", d.scaleUpThreshold, scaler.Spec.Evaluations)
+	} else {
+		d.reason = fmt.Sprintf("utilization below %d%% for %d evaluations", d.scaleDownThreshold, scaler.Spec.Evaluations)
+	}
+	return d, nil
+}
+
+// applyCooldown blocks a decision which changes the replicas while the target is in cooldown
+func applyCooldown(d decision, scaler *v1alpha1.Scaler, now time.Time) decision {
+	if d.desiredReplicas == d.currentReplicas || !inCooldown(scaler, now) {
+		return d
+	}
+	d.blocked = true
+	d.cooldown = true
+	d.reason = fmt.Sprintf("still in cooldown period since last scaling at %s", scaler.Status.LastScalingTimestamp)
+	return d
+}
+
+// inCooldown returns true if the target was scaled too recently to be scaled again
+func inCooldown(scaler *v1alpha1.Scaler, now time.Time) bool {
+	lastUpdated, err := time.Parse(time.RFC3339, scaler.Status.LastScalingTimestamp)
+	if err != nil {
+		log.Debugf("failed to find last updated time")
+		return false
+	}
+	return lastUpdated.Add(scalingCooldown).After(now)
+}
+
+// dryRun returns true if the controller must not change the target of the Scaler
+func (c *Controller) dryRun(scaler *v1alpha1.Scaler) bool {
+	return c.options.DryRun || scaler.Spec.DryRun
+}
+
+// recommend records the decision in the status of a Scaler in dry run mode instead of applying it
+func (c *Controller) recommend(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale, d decision) error {
+	log.Infof("dry run for %s/%s: %d replicas recommended: %s", scaler.Namespace, scaler.Name, d.desiredReplicas, d.reason)
+	if scaler.Status.DesiredReplicas == d.desiredReplicas && scaler.Status.Reason == d.reason {
+		return nil
+	}
+
+	if d.scale() {
+		c.recorder.Eventf(scaler, corev1.EventTypeNormal, ScalingRecommended,
+			"would scale target %s/%s from %d to %d replicas: %s", scale.Namespace, scale.Name,
+			d.currentReplicas, d.desiredReplicas, d.reason)
+	}
+	_, err := c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		status.Condition = fmt.Sprintf("Recommended %d replicas", d.desiredReplicas)
+		status.DesiredReplicas = d.desiredReplicas
+		status.Reason = d.reason
+		if d.scale() || d.blocked {
+			c.recordDecision(status, d.record(true))
+		}
+	})
+	return err
+}
+
+// record converts the decision into a history entry
+func (d decision) record(dryRun bool) v1alpha1.ScalingDecision {
+	direction := v1alpha1.ScalingUp
+	if d.desiredReplicas < d.currentReplicas {
+		direction = v1alpha1.ScalingDown
+	}
+	return v1alpha1.ScalingDecision{
+		Time:         metav1.Now(),
+		FromReplicas: d.currentReplicas,
+		ToReplicas:   d.desiredReplicas,
+		Direction:    direction,
+		Utilization: v1alpha1.UtilizationSummary{
+			Average: d.utilization.Average,
+			Min:     d.utilization.Min,
+			Max:     d.utilization.Max,
+			Pods:    d.utilization.Pods,
+		},
+		ScaleUpThreshold:   d.scaleUpThreshold,
+		ScaleDownThreshold: d.scaleDownThreshold,
+		MinReplicas:        d.minReplicas,
+		MaxReplicas:        d.maxReplicas,
+		Reason:             d.reason,
+		Blocked:            d.blocked,
+		DryRun:             dryRun,
+	}
+}
+
+// recordDecision appends the decision to the history in the status and drops the oldest
+// entries beyond the configured length. A decision which repeats the last entry is not
+// recorded again so that a blocked decision does not fill the history on every resync.
+func (c *Controller) recordDecision(status *v1alpha1.ScalerStatus, entry v1alpha1.ScalingDecision) {
+	status.History = appendHistory(status.History, entry, c.options.HistoryLength)
+}
+
+// appendHistory adds the entry to the history unless it repeats the last one and keeps at
+// most length entries
+func appendHistory(history []v1alpha1.ScalingDecision, entry v1alpha1.ScalingDecision, length int) []v1alpha1.ScalingDecision {
+	if length <= 0 {
+		return nil
+	}
+	if n := len(history); n > 0 && sameDecision(history[n-1], entry) {
+		return history
+	}
+	history = append(history, entry)
+	if len(history) > length {
+		history = append([]v1alpha1.ScalingDecision(nil), history[len(history)-length:]...)
+	}
+	return history
+}
+
+func sameDecision(a, b v1alpha1.ScalingDecision) bool {
+	return a.FromReplicas == b.FromReplicas && a.ToReplicas == b.ToReplicas && a.Blocked == b.Blocked &&
+		a.DryRun == b.DryRun && a.Reason == b.Reason
+}
+
+// recordBlocked publishes a decision which could not be applied and adds it to the history.
+// The last blocked decision is remembered so that it is only published again when it changes,
+// also when the history is disabled.
+func (c *Controller) recordBlocked(scaler *v1alpha1.Scaler, d decision) error {
+	entry := d.record(false)
+	value := fmt.Sprintf("%d %d %s", entry.FromReplicas, entry.ToReplicas, entry.Reason)
+	if !c.reported.changed(scalerKey(scaler), EventBlocked, value) {
+		return nil
+	}
+	c.publish(EventBlocked, scaler, entry)
+	if c.options.HistoryLength <= 0 {
+		return nil
+	}
+	_, err := c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		c.recordDecision(status, entry)
+	})
+	return err
+}
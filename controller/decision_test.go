@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"testing"
+)
+
+func TestAppendHistory(t *testing.T) {
+	entry := func(from, to int32, blocked bool) v1alpha1.ScalingDecision {
+		return v1alpha1.ScalingDecision{FromReplicas: from, ToReplicas: to, Blocked: blocked, Reason: "test"}
+	}
+
+	testCases := []struct {
+		name     string
+		history  []v1alpha1.ScalingDecision
+		entry    v1alpha1.ScalingDecision
+		length   int
+		expected []v1alpha1.ScalingDecision
+	}{
+		{
+			name:     "empty history",
+			entry:    entry(1, 2, false),
+			length:   3,
+			expected: []v1alpha1.ScalingDecision{entry(1, 2, false)},
+		},
+		{
+			name:     "oldest entry is dropped",
+			history:  []v1alpha1.ScalingDecision{entry(1, 2, false), entry(2, 3, false), entry(3, 4, false)},
+			entry:    entry(4, 5, false),
+			length:   3,
+			expected: []v1alpha1.ScalingDecision{entry(2, 3, false), entry(3, 4, false), entry(4, 5, false)},
+		},
+		{
+			name:     "repeated blocked decision is recorded once",
+			history:  []v1alpha1.ScalingDecision{entry(1, 2, true)},
+			entry:    entry(1, 2, true),
+			length:   3,
+			expected: []v1alpha1.ScalingDecision{entry(1, 2, true)},
+		},
+		{
+			name:     "applied decision after a blocked one",
+			history:  []v1alpha1.ScalingDecision{entry(1, 2, true)},
+			entry:    entry(1, 2, false),
+			length:   3,
+			expected: []v1alpha1.ScalingDecision{entry(1, 2, true), entry(1, 2, false)},
+		},
+		{
+			name:    "history disabled",
+			history: []v1alpha1.ScalingDecision{entry(1, 2, false)},
+			entry:   entry(2, 3, false),
+			length:  0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, appendHistory(tc.history, tc.entry, tc.length))
+		})
+	}
+}
+
+type fakePublisher struct {
+	events []string
+}
+
+func (p *fakePublisher) Publish(eventType, subject string, data interface{}) bool {
+	p.events = append(p.events, eventType)
+	return true
+}
+
+func TestRecordBlockedWithoutHistory(t *testing.T) {
+	publisher := &fakePublisher{}
+	c := &Controller{reported: newReported(), options: Options{Publisher: publisher}}
+	scaler := &v1alpha1.Scaler{}
+	scaler.Namespace, scaler.Name = "default", "web"
+	d := decision{currentReplicas: 10, desiredReplicas: 12, blocked: true, reason: "at the maximum of 10 replicas"}
+
+	assert.NoError(t, c.recordBlocked(scaler, d))
+	assert.NoError(t, c.recordBlocked(scaler, d))
+	assert.Len(t, publisher.events, 1, "a repeated decision is not published again")
+
+	d.desiredReplicas = 14
+	assert.NoError(t, c.recordBlocked(scaler, d))
+	assert.Len(t, publisher.events, 2)
+
+	c.reported.changed(scalerKey(scaler), EventBlocked, "")
+	assert.NoError(t, c.recordBlocked(scaler, d))
+	assert.Len(t, publisher.events, 3, "a decision is published again after the target was scaled")
+}
+
+func TestRecommend(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options Options
+		dryRun  bool
+	}{
+		{name: "scaler in dry run", dryRun: true},
+		{name: "controller in dry run", options: Options{DryRun: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaler := &v1alpha1.Scaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+				Spec: v1alpha1.ScalerSpec{
+					Target: v1alpha1.ScaleTarget{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+					DryRun: tc.dryRun,
+				},
+			}
+			replicas := int32(2)
+			target := &fakeTarget{replicas: &replicas}
+			c := newTargetController(scaler, target)
+			c.options = tc.options
+			c.options.HistoryLength = 10
+			require.True(t, c.dryRun(scaler))
+
+			scale := &autoscalingv1.Scale{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+				Spec: autoscalingv1.ScaleSpec{Replicas: 2}}
+			d := decision{currentReplicas: 2, desiredReplicas: 4, reason: "utilization above the threshold"}
+			require.NoError(t, c.recommend(scaler, scale, d))
+
+			assert.Empty(t, target.updates, "the target is not scaled in dry run mode")
+			recorder := c.recorder.(*record.FakeRecorder)
+			require.Len(t, recorder.Events, 1)
+			assert.Contains(t, <-recorder.Events, ScalingRecommended)
+
+			stored, err := c.scalerclientset.ArjunnaikV1alpha1().Scalers("default").Get("web", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, int32(4), stored.Status.DesiredReplicas)
+			require.Len(t, stored.Status.History, 1)
+			entry := stored.Status.History[0]
+			assert.True(t, entry.DryRun)
+			assert.Equal(t, int32(2), entry.FromReplicas)
+			assert.Equal(t, int32(4), entry.ToReplicas)
+
+			require.NoError(t, c.recommend(stored, scale, d))
+			assert.Empty(t, recorder.Events, "an unchanged recommendation is not recorded again")
+			assert.Empty(t, target.updates)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"time"
+)
+
+const (
+	// FinalizerName is added to Scalers with an OnDelete policy which changes the target, so
+	// that the controller gets to apply the policy before the Scaler is removed.
+	FinalizerName = "arjunnaik.in/on-delete"
+
+	TargetRestored   = "TargetRestored"
+	ErrRestoreTarget = "ErrRestoreTarget"
+)
+
+// deleteScaler drops everything the controller keeps in memory for a deleted Scaler
+func (c *Controller) deleteScaler(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	log.Infof("Scaler %s has been deleted", key)
+	c.forgetScaler(key)
+
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	// a Scaler which was held back by a conflict with this one can take over the target now
+	if scaler, ok := obj.(*v1alpha1.Scaler); ok {
+		c.enqueueScalersForTarget(scalerTargetKey(scaler))
+	}
+}
+
+// forgetScaler clears the per-scaler state held by the controller
+func (c *Controller) forgetScaler(key string) {
+	c.queue.Forget(key)
+	for _, cleanup := range c.cleanups {
+		cleanup(key)
+	}
+}
+
+// needsFinalizer returns true when deleting the Scaler has to change the target
+func needsFinalizer(scaler *v1alpha1.Scaler) bool {
+	return scaler.Spec.OnDelete != nil && scaler.Spec.OnDelete.Policy != "" &&
+		scaler.Spec.OnDelete.Policy != v1alpha1.OnDeleteRetain
+}
+
+func hasFinalizer(scaler *v1alpha1.Scaler) bool {
+	for _, f := range scaler.Finalizers {
+		if f == FinalizerName {
+			return true
+		}
+	}
+	return false
+}
+
+// syncFinalizer adds the finalizer to Scalers which need it and removes it from the ones which
+// no longer do, for example after the OnDelete policy was changed to Retain.
+func (c *Controller) syncFinalizer(scaler *v1alpha1.Scaler) (*v1alpha1.Scaler, error) {
+	if needsFinalizer(scaler) == hasFinalizer(scaler) {
+		return scaler, nil
+	}
+	return c.updateScaler(scaler, func(s *v1alpha1.Scaler) {
+		if needsFinalizer(s) {
+			if !hasFinalizer(s) {
+				s.Finalizers = append(s.Finalizers, FinalizerName)
+			}
+			return
+		}
+		s.Finalizers = removeFinalizer(s.Finalizers)
+	})
+}
+
+// finalizeScaler applies the OnDelete policy of a Scaler which is being deleted and then
+// removes the finalizer so that the deletion can complete. While a freeze forbids the change the
+// finalizer is kept.
+func (c *Controller) finalizeScaler(scaler *v1alpha1.Scaler) error {
+	if !hasFinalizer(scaler) {
+		return nil
+	}
+
+	if needsFinalizer(scaler) {
+		applied, err := c.applyOnDelete(scaler)
+		if err != nil {
+			c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrRestoreTarget,
+				"failed to apply the on delete policy to %s/%s: %v", scaler.Namespace, scaler.Spec.Target.Name, err)
+			return err
+		}
+		if !applied {
+			return nil
+		}
+	}
+
+	_, err := c.updateScaler(scaler, func(s *v1alpha1.Scaler) {
+		s.Finalizers = removeFinalizer(s.Finalizers)
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// applyOnDelete sets the target to the replicas of the OnDelete policy. It returns false when a
+// freeze forbids the change, and the Scaler is queued again for the time the freeze may end.
+func (c *Controller) applyOnDelete(scaler *v1alpha1.Scaler) (bool, error) {
+	var replicas int32
+	switch scaler.Spec.OnDelete.Policy {
+	case v1alpha1.OnDeleteRestore:
+		if scaler.Status.OriginalReplicas == nil {
+			log.Warnf("original replicas of the target of %s/%s are unknown. leaving it as it is",
+				scaler.Namespace, scaler.Name)
+			return true, nil
+		}
+		replicas = *scaler.Status.OriginalReplicas
+	case v1alpha1.OnDeleteFixed:
+		replicas = scaler.Spec.OnDelete.Replicas
+	default:
+		log.Warnf("unknown on delete policy %q on %s/%s", scaler.Spec.OnDelete.Policy, scaler.Namespace, scaler.Name)
+		return true, nil
+	}
+
+	scale, targetGR, err := c.targetScale(scaler)
+	if errors.IsNotFound(err) {
+		log.Infof("target of %s/%s has already been deleted", scaler.Namespace, scaler.Name)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if scale.Spec.Replicas == replicas {
+		return true, nil
+	}
+	if c.dryRun(scaler) {
+		log.Infof("dry run for %s/%s: would set target to %d replicas on delete", scaler.Namespace, scaler.Name, replicas)
+		return true, nil
+	}
+
+	scalingFreezes, err := c.scalingFreezes()
+	if err != nil {
+		return false, err
+	}
+	active, boundary, _ := activeFreezes(scaler, scalingFreezes, time.Now())
+	if f := blockingFreeze(active, decision{currentReplicas: scale.Spec.Replicas, desiredReplicas: replicas}); f != nil {
+		key := scalerKey(scaler)
+		if c.reported.changed(key, ScalingSuppressed, fmt.Sprintf("on delete %d %s", replicas, f)) {
+			log.Infof("not setting the target of %s/%s to %d replicas on delete: %s", scaler.Namespace, scaler.Name,
+				replicas, f)
+			c.recorder.Eventf(scaler, corev1.EventTypeNormal, ScalingSuppressed,
+				"not setting the target to %d replicas on delete until the freeze ends: %s", replicas, f)
+		}
+		if !boundary.IsZero() {
+			c.queue.AddAfter(key, time.Until(boundary))
+		}
+		return false, nil
+	}
+
+	from := scale.Spec.Replicas
+	scale.Spec.Replicas = replicas
+	if _, err = c.scaleNamespacer.Scales(scale.Namespace).Update(targetGR, scale); err != nil {
+		return false, err
+	}
+	c.recorder.Eventf(scaler, corev1.EventTypeNormal, TargetRestored, "set target %s/%s to %d replicas on delete",
+		scale.Namespace, scale.Name, replicas)
+	c.audit(scaler, replicaChange(from, replicas, string(scaler.Spec.OnDelete.Policy)+" policy on delete"), nil)
+	return true, nil
+}
+
+// updateScaler applies the mutation to the Scaler and writes it, retrying with the latest
+// version from the API server on conflicts.
+func (c *Controller) updateScaler(scaler *v1alpha1.Scaler, mutate func(s *v1alpha1.Scaler)) (*v1alpha1.Scaler, error) {
+	scalers := c.scalerclientset.ArjunnaikV1alpha1().Scalers(scaler.Namespace)
+	latest := scaler.DeepCopy()
+	var updated *v1alpha1.Scaler
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mutate(latest)
+		var err error
+		updated, err = scalers.Update(latest)
+		if !errors.IsConflict(err) {
+			return err
+		}
+		fresh, getErr := scalers.Get(scaler.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		latest = fresh
+		return err
+	})
+	return updated, err
+}
+
+func removeFinalizer(finalizers []string) []string {
+	var result []string
+	for _, f := range finalizers {
+		if f != FinalizerName {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned/fake"
+	listers "github.com/arjunrn/simple-scaler/pkg/client/listers/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	scalefake "k8s.io/client-go/scale/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"testing"
+	"time"
+)
+
+// fakeTarget is the Deployment web behind a fake scale client
+type fakeTarget struct {
+	// replicas is nil when the Deployment does not exist
+	replicas *int32
+	// updates are the replicas the target was set to
+	updates   []int32
+	updateErr error
+	// beforeUpdate is called before the target is updated
+	beforeUpdate func()
+}
+
+// newTargetController returns a controller with fake clients for the Scaler and its target
+func newTargetController(scaler *v1alpha1.Scaler, target *fakeTarget, freezes ...*v1alpha1.ScalingFreeze) *Controller {
+	scales := &scalefake.FakeScaleClient{}
+	scales.AddReactor("get", "deployments", func(action core.Action) (bool, runtime.Object, error) {
+		if target.replicas == nil {
+			return true, nil, errors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web")
+		}
+		return true, &autoscalingv1.Scale{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Spec: autoscalingv1.ScaleSpec{Replicas: *target.replicas}}, nil
+	})
+	scales.AddReactor("update", "deployments", func(action core.Action) (bool, runtime.Object, error) {
+		if target.beforeUpdate != nil {
+			target.beforeUpdate()
+		}
+		if target.updateErr != nil {
+			return true, nil, target.updateErr
+		}
+		scale := action.(core.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		replicas := scale.Spec.Replicas
+		target.replicas = &replicas
+		target.updates = append(target.updates, replicas)
+		return true, scale, nil
+	})
+
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "apps", Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, apimeta.RESTScopeNamespace)
+	freezeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, f := range freezes {
+		freezeIndexer.Add(f)
+	}
+	return &Controller{
+		scalerclientset: fake.NewSimpleClientset(scaler),
+		scaleNamespacer: scales,
+		mapper:          mapper,
+		freezesLister:   listers.NewScalingFreezeLister(freezeIndexer),
+		recorder:        record.NewFakeRecorder(10),
+		reported:        newReported(),
+		queue:           workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "scalers"),
+	}
+}
+
+// newDeletedScaler returns a Scaler of the Deployment web which is being deleted
+func newDeletedScaler(onDelete *v1alpha1.OnDelete) *v1alpha1.Scaler {
+	now := metav1.Now()
+	return &v1alpha1.Scaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", DeletionTimestamp: &now,
+			Finalizers: []string{FinalizerName}},
+		Spec: v1alpha1.ScalerSpec{
+			Target:   v1alpha1.ScaleTarget{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			OnDelete: onDelete,
+		},
+	}
+}
+
+// finalizers returns the finalizers of the Scaler as stored by the fake clientset
+func finalizers(t *testing.T, c *Controller, scaler *v1alpha1.Scaler) []string {
+	stored, err := c.scalerclientset.ArjunnaikV1alpha1().Scalers(scaler.Namespace).Get(scaler.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return stored.Finalizers
+}
+
+func TestFinalizeScaler(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	testCases := []struct {
+		name      string
+		onDelete  *v1alpha1.OnDelete
+		original  *int32
+		dryRun    bool
+		replicas  *int32
+		updateErr error
+		updates   []int32
+		err       bool
+	}{
+		{name: "retain", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteRetain}, replicas: int32Ptr(8)},
+		{name: "restore", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteRestore}, original: int32Ptr(3),
+			replicas: int32Ptr(8), updates: []int32{3}},
+		{name: "restore without the original replicas", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteRestore},
+			replicas: int32Ptr(8)},
+		{name: "fixed", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteFixed, Replicas: 2}, replicas: int32Ptr(8),
+			updates: []int32{2}},
+		{name: "already at the replicas", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteFixed, Replicas: 2},
+			replicas: int32Ptr(2)},
+		{name: "target is gone", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteFixed, Replicas: 2}},
+		{name: "dry run", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteFixed, Replicas: 2}, dryRun: true,
+			replicas: int32Ptr(8)},
+		{name: "scale update fails", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteFixed, Replicas: 2},
+			replicas: int32Ptr(8), updateErr: errors.NewServiceUnavailable("try again"), err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaler := newDeletedScaler(tc.onDelete)
+			scaler.Spec.DryRun = tc.dryRun
+			scaler.Status.OriginalReplicas = tc.original
+			target := &fakeTarget{replicas: tc.replicas, updateErr: tc.updateErr}
+			c := newTargetController(scaler, target)
+
+			err := c.finalizeScaler(scaler)
+			if tc.err {
+				assert.Error(t, err)
+				assert.Equal(t, []string{FinalizerName}, finalizers(t, c, scaler),
+					"the finalizer is only removed after the target was updated")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.updates, target.updates)
+			assert.Empty(t, finalizers(t, c, scaler))
+		})
+	}
+}
+
+func TestSyncFinalizer(t *testing.T) {
+	testCases := []struct {
+		name       string
+		onDelete   *v1alpha1.OnDelete
+		finalizers []string
+		expected   []string
+	}{
+		{name: "added for a policy which changes the target",
+			onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteFixed, Replicas: 2},
+			expected: []string{FinalizerName}},
+		{name: "kept", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteRestore},
+			finalizers: []string{"other", FinalizerName}, expected: []string{"other", FinalizerName}},
+		{name: "removed after changing to retain", onDelete: &v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteRetain},
+			finalizers: []string{"other", FinalizerName}, expected: []string{"other"}},
+		{name: "not needed without a policy", finalizers: []string{"other"}, expected: []string{"other"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaler := newDeletedScaler(tc.onDelete)
+			scaler.DeletionTimestamp = nil
+			scaler.Finalizers = tc.finalizers
+			c := newTargetController(scaler, &fakeTarget{})
+
+			updated, err := c.syncFinalizer(scaler)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, updated.Finalizers)
+			assert.Equal(t, tc.expected, finalizers(t, c, scaler))
+		})
+	}
+}
+
+func TestFinalizeScalerDuringFreeze(t *testing.T) {
+	replicas := int32(8)
+	target := &fakeTarget{replicas: &replicas}
+	scaler := newDeletedScaler(&v1alpha1.OnDelete{Policy: v1alpha1.OnDeleteFixed, Replicas: 2})
+	end := metav1.NewTime(time.Now().Add(time.Hour))
+	release := &v1alpha1.ScalingFreeze{ObjectMeta: metav1.ObjectMeta{Name: "release"},
+		Spec: v1alpha1.ScalingFreezeSpec{FreezeWindow: v1alpha1.FreezeWindow{End: &end, Direction: v1alpha1.FreezeDown}}}
+	c := newTargetController(scaler, target, release)
+
+	assert.NoError(t, c.finalizeScaler(scaler))
+	assert.Empty(t, target.updates, "the target is not scaled down during the freeze")
+	assert.Equal(t, []string{FinalizerName}, finalizers(t, c, scaler), "the finalizer is kept until the freeze ends")
+
+	// the freeze is deleted
+	c.freezesLister = listers.NewScalingFreezeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+	assert.NoError(t, c.finalizeScaler(scaler))
+	assert.Equal(t, []int32{2}, target.updates)
+	assert.Empty(t, finalizers(t, c, scaler))
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"time"
+)
+
+const (
+	ManualOverrideDetected = "ManualOverrideDetected"
+	ManualOverrideExpired  = "ManualOverrideExpired"
+	ManualOverrideCleared  = "ManualOverrideCleared"
+)
+
+// driftGracePeriod returns how long a manual change to the replicas of the target is respected
+func (c *Controller) driftGracePeriod(scaler *v1alpha1.Scaler) time.Duration {
+	if scaler.Spec.DriftGracePeriodSeconds != nil {
+		return time.Duration(*scaler.Spec.DriftGracePeriodSeconds) * time.Second
+	}
+	return c.options.DriftGracePeriod
+}
+
+// syncDrift detects changes to the replicas of the target which were not made by the controller.
+// It returns true while such a manual override is being respected and scaling must not happen.
+func (c *Controller) syncDrift(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale) (bool, *v1alpha1.Scaler, error) {
+	var err error
+	overriding := isConditionTrue(&scaler.Status, v1alpha1.ScalerManualOverride)
+	detectedAt, annotated := scaler.Annotations[v1alpha1.ManualOverrideAnnotation]
+
+	if overriding {
+		if !annotated {
+			log.Infof("manual override of %s/%s was cleared", scaler.Namespace, scaler.Name)
+			c.recorder.Eventf(scaler, corev1.EventTypeNormal, ManualOverrideCleared,
+				"manual override cleared. resuming scaling from %d replicas", scale.Spec.Replicas)
+			scaler, err = c.resumeFromOverride(scaler, scale, ManualOverrideCleared, "the manual override annotation was removed")
+			return false, scaler, err
+		}
+
+		since, parseErr := time.Parse(time.RFC3339, detectedAt)
+		if parseErr != nil {
+			log.Warnf("invalid %s annotation on %s/%s: %v", v1alpha1.ManualOverrideAnnotation, scaler.Namespace, scaler.Name, parseErr)
+			since = time.Now()
+		}
+		if since.Add(c.driftGracePeriod(scaler)).After(time.Now()) {
+			log.Infof("respecting manual override of %s/%s since %s", scaler.Namespace, scaler.Name, detectedAt)
+			return true, scaler, nil
+		}
+
+		c.recorder.Eventf(scaler, corev1.EventTypeNormal, ManualOverrideExpired,
+			"manual override grace period expired. resuming scaling from %d replicas", scale.Spec.Replicas)
+		scaler, err = c.updateScaler(scaler, func(s *v1alpha1.Scaler) {
+			delete(s.Annotations, v1alpha1.ManualOverrideAnnotation)
+		})
+		if err != nil {
+			return true, scaler, err
+		}
+		scaler, err = c.resumeFromOverride(scaler, scale, ManualOverrideExpired, "the grace period of the manual override expired")
+		return false, scaler, err
+	}
+
+	lastApplied := scaler.Status.LastAppliedReplicas
+	if lastApplied == nil || *lastApplied == scale.Spec.Replicas {
+		return false, scaler, nil
+	}
+
+	message := fmt.Sprintf("replicas of %s were changed from %d to %d outside of the controller",
+		describeTarget(scaler), *lastApplied, scale.Spec.Replicas)
+	log.Infof("Scaler %s/%s: %s", scaler.Namespace, scaler.Name, message)
+	c.recorder.Eventf(scaler, corev1.EventTypeWarning, ManualOverrideDetected, "%s. not scaling for %s",
+		message, c.driftGracePeriod(scaler))
+
+	now := time.Now().Format(time.RFC3339)
+	scaler, err = c.updateScaler(scaler, func(s *v1alpha1.Scaler) {
+		if s.Annotations == nil {
+			s.Annotations = map[string]string{}
+		}
+		s.Annotations[v1alpha1.ManualOverrideAnnotation] = now
+	})
+	if err != nil {
+		return true, scaler, err
+	}
+	scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		setCondition(status, v1alpha1.ScalerManualOverride, corev1.ConditionTrue, ManualOverrideDetected, message)
+	})
+	return true, scaler, err
+}
+
+// updateTargetReplicas sets the target to the replicas. They are recorded as the last applied
+// replicas before the target is updated, so that a status update which fails afterwards does not
+// make the change look like a manual one on the next reconcile. If the target cannot be updated
+// the previous value is restored.
+func (c *Controller) updateTargetReplicas(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale,
+	targetGR schema.GroupResource, replicas int32) (*v1alpha1.Scaler, error) {
+	previous := scaler.Status.LastAppliedReplicas
+	if previous == nil || *previous != replicas {
+		updated, err := c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+			status.LastAppliedReplicas = &replicas
+		})
+		if err != nil {
+			return scaler, fmt.Errorf("failed to record the replicas before updating the target: %v", err)
+		}
+		scaler = updated
+	}
+
+	scale.Spec.Replicas = replicas
+	if _, err := c.scaleNamespacer.Scales(scale.Namespace).Update(targetGR, scale); err != nil {
+		if restored, statusErr := c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+			status.LastAppliedReplicas = previous
+		}); statusErr != nil {
+			log.Errorf("failed to restore the last applied replicas of %s/%s: %v", scaler.Namespace, scaler.Name, statusErr)
+		} else {
+			scaler = restored
+		}
+		return scaler, err
+	}
+	return scaler, nil
+}
+
+// resumeFromOverride adopts the replicas set by hand as the new baseline and clears the condition
+func (c *Controller) resumeFromOverride(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale, reason,
+	message string) (*v1alpha1.Scaler, error) {
+	replicas := scale.Spec.Replicas
+	return c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		status.LastAppliedReplicas = &replicas
+		setCondition(status, v1alpha1.ScalerManualOverride, corev1.ConditionFalse, reason, message)
+	})
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	core "k8s.io/client-go/testing"
+	"testing"
+	"time"
+)
+
+func TestSyncDrift(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	overrideMessage := "replicas of Deployment/web were changed from 3 to 5 outside of the controller"
+	testCases := []struct {
+		name        string
+		lastApplied *int32
+		detectedAt  *time.Time
+		// overriding is true when the Scaler already respects a manual override
+		overriding bool
+		overridden bool
+		annotated  bool
+		baseline   int32
+	}{
+		{name: "no drift", lastApplied: int32Ptr(5), baseline: 5},
+		{name: "nothing applied yet"},
+		{name: "drift is detected", lastApplied: int32Ptr(3), overridden: true, annotated: true, baseline: 3},
+		{name: "within the grace period", lastApplied: int32Ptr(3), detectedAt: timePtr(time.Now().Add(-time.Minute)),
+			overriding: true, overridden: true, annotated: true, baseline: 3},
+		{name: "grace period expired", lastApplied: int32Ptr(3), detectedAt: timePtr(time.Now().Add(-time.Hour)),
+			overriding: true, baseline: 5},
+		{name: "annotation removed", lastApplied: int32Ptr(3), overriding: true, baseline: 5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaler := &v1alpha1.Scaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+				Spec: v1alpha1.ScalerSpec{
+					Target: v1alpha1.ScaleTarget{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+				Status: v1alpha1.ScalerStatus{LastAppliedReplicas: tc.lastApplied},
+			}
+			if tc.detectedAt != nil {
+				scaler.Annotations = map[string]string{
+					v1alpha1.ManualOverrideAnnotation: tc.detectedAt.Format(time.RFC3339)}
+			}
+			if tc.overriding {
+				setCondition(&scaler.Status, v1alpha1.ScalerManualOverride, corev1.ConditionTrue,
+					ManualOverrideDetected, overrideMessage)
+			}
+			replicas := int32(5)
+			c := newTargetController(scaler, &fakeTarget{replicas: &replicas})
+			c.options.DriftGracePeriod = 10 * time.Minute
+			scale := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: replicas}}
+
+			overridden, updated, err := c.syncDrift(scaler, scale)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.overridden, overridden)
+			stored, err := c.scalerclientset.ArjunnaikV1alpha1().Scalers("default").Get("web", metav1.GetOptions{})
+			assert.NoError(t, err)
+			_, annotated := stored.Annotations[v1alpha1.ManualOverrideAnnotation]
+			assert.Equal(t, tc.annotated, annotated)
+			assert.Equal(t, tc.overridden, isConditionTrue(&updated.Status, v1alpha1.ScalerManualOverride))
+			if tc.baseline == 0 {
+				assert.Nil(t, updated.Status.LastAppliedReplicas)
+			} else {
+				assert.Equal(t, tc.baseline, *updated.Status.LastAppliedReplicas,
+					"the replicas set by hand are the new baseline once the override ends")
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestUpdateTargetReplicas(t *testing.T) {
+	replicas := int32(3)
+	target := &fakeTarget{replicas: &replicas}
+	scaler := &v1alpha1.Scaler{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Status: v1alpha1.ScalerStatus{LastAppliedReplicas: &replicas}}
+	c := newTargetController(scaler, target)
+	targetGR := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	scale := &autoscalingv1.Scale{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: autoscalingv1.ScaleSpec{Replicas: 3}}
+
+	// the status is written before the target is updated
+	clientset := c.scalerclientset.(*fake.Clientset)
+	var appliedWhenScaled *int32
+	target.beforeUpdate = func() {
+		stored, _ := clientset.ArjunnaikV1alpha1().Scalers("default").Get("web", metav1.GetOptions{})
+		appliedWhenScaled = stored.Status.LastAppliedReplicas
+	}
+	updated, err := c.updateTargetReplicas(scaler, scale, targetGR, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{6}, target.updates)
+	assert.Equal(t, int32(6), *appliedWhenScaled)
+	assert.Equal(t, int32(6), *updated.Status.LastAppliedReplicas)
+
+	// the previous value is restored when the target cannot be updated
+	target.updateErr = errors.NewServiceUnavailable("try again")
+	updated, err = c.updateTargetReplicas(updated, scale, targetGR, 8)
+	assert.Error(t, err)
+	assert.Equal(t, int32(6), *updated.Status.LastAppliedReplicas)
+
+	// nothing is scaled when the replicas cannot be recorded
+	target.updateErr = nil
+	clientset.PrependReactor("update", "scalers", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewServiceUnavailable("try again")
+	})
+	_, err = c.updateTargetReplicas(updated, scale, targetGR, 8)
+	assert.Error(t, err)
+	assert.Equal(t, []int32{6}, target.updates)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	"k8s.io/apimachinery/pkg/labels"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Evaluation is the outcome of the latest evaluation of a Scaler. Unlike the history in the
+// status it is kept for every evaluation, also when nothing changed, but only in memory.
+type Evaluation struct {
+	Time               time.Time
+	CurrentReplicas    int32
+	DesiredReplicas    int32
+	MinReplicas        int32
+	MaxReplicas        int32
+	ScaleUpThreshold   int32
+	ScaleDownThreshold int32
+	Blocked            bool
+	Reason             string
+	Utilization        replicacalculator.Utilization
+	// Window is the average utilization of the pods at every sample of the metric window,
+	// oldest first
+	Window []int32
+}
+
+// evaluations keeps the latest evaluation of every Scaler
+type evaluations struct {
+	mu    sync.RWMutex
+	byKey map[string]Evaluation
+}
+
+func newEvaluations() *evaluations {
+	return &evaluations{byKey: map[string]Evaluation{}}
+}
+
+func (e *evaluations) get(key string) (Evaluation, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	evaluation, ok := e.byKey[key]
+	return evaluation, ok
+}
+
+func (e *evaluations) set(key string, evaluation Evaluation) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.byKey[key] = evaluation
+}
+
+func (e *evaluations) forget(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.byKey, key)
+}
+
+// evaluation converts the decision into the evaluation which is kept in memory
+func (d decision) evaluation(now time.Time) Evaluation {
+	return Evaluation{
+		Time:               now,
+		CurrentReplicas:    d.currentReplicas,
+		DesiredReplicas:    d.desiredReplicas,
+		MinReplicas:        d.minReplicas,
+		MaxReplicas:        d.maxReplicas,
+		ScaleUpThreshold:   d.scaleUpThreshold,
+		ScaleDownThreshold: d.scaleDownThreshold,
+		Blocked:            d.blocked,
+		Reason:             d.reason,
+		Utilization:        d.utilization,
+		Window:             utilizationWindow(d.podMetrics),
+	}
+}
+
+// utilizationWindow averages the samples of all the pods at every position of the window. The
+// samples of the pods end at the same time, so pods with fewer samples are aligned to the end.
+func utilizationWindow(podMetrics map[string][]int) []int32 {
+	length := 0
+	for _, samples := range podMetrics {
+		if len(samples) > length {
+			length = len(samples)
+		}
+	}
+	if length == 0 {
+		return nil
+	}
+	totals := make([]int, length)
+	counts := make([]int, length)
+	for _, samples := range podMetrics {
+		offset := length - len(samples)
+		for i, s := range samples {
+			totals[offset+i] += s
+			counts[offset+i]++
+		}
+	}
+	window := make([]int32, length)
+	for i := range window {
+		window[i] = int32(totals[i] / counts[i])
+	}
+	return window
+}
+
+// Scalers returns the Scalers handled by the controller from the informer cache, sorted by
+// namespace and name. They must not be modified.
+func (c *Controller) Scalers() ([]*v1alpha1.Scaler, error) {
+	scalers, err := c.scalersLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(scalers, func(i, j int) bool {
+		if scalers[i].Namespace != scalers[j].Namespace {
+			return scalers[i].Namespace < scalers[j].Namespace
+		}
+		return scalers[i].Name < scalers[j].Name
+	})
+	return scalers, nil
+}
+
+// Scaler returns the Scaler from the informer cache. It must not be modified.
+func (c *Controller) Scaler(namespace, name string) (*v1alpha1.Scaler, error) {
+	return c.scalersLister.Scalers(namespace).Get(name)
+}
+
+// LastEvaluation returns the latest evaluation of the Scaler by this replica of the controller
+func (c *Controller) LastEvaluation(namespace, name string) (Evaluation, bool) {
+	return c.evaluations.get(namespace + "/" + name)
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUtilizationWindow(t *testing.T) {
+	testCases := []struct {
+		name       string
+		podMetrics map[string][]int
+		expected   []int32
+	}{
+		{name: "no samples", podMetrics: map[string][]int{"abc": {}}, expected: nil},
+		{name: "one pod", podMetrics: map[string][]int{"abc": {10, 20, 30}}, expected: []int32{10, 20, 30}},
+		{name: "several pods", podMetrics: map[string][]int{"abc": {10, 20, 30}, "def": {30, 40, 50}},
+			expected: []int32{20, 30, 40}},
+		{name: "new pod is aligned to the end", podMetrics: map[string][]int{"abc": {10, 20, 30}, "def": {50}},
+			expected: []int32{10, 20, 40}},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, utilizationWindow(c.podMetrics))
+		})
+	}
+}
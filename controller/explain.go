@@ -0,0 +1,404 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	clientset "github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	scaleclient "k8s.io/client-go/scale"
+	"sort"
+	"time"
+)
+
+// Gate is a check which keeps the controller from applying the replicas it computed
+type Gate string
+
+const (
+	GateDisabled        Gate = "disabled"
+	GatePaused          Gate = "paused"
+	GatePinned          Gate = "pinned"
+	GateConflict        Gate = "conflict"
+	GateRollout         Gate = "rollout"
+	GateManualOverride  Gate = "manual override"
+	GateZeroReplicas    Gate = "zero replicas"
+	GateMissingSelector Gate = "missing selector"
+	GateBounds          Gate = "bounds"
+	GateIdle            Gate = "idle"
+	GateEquality        Gate = "equality"
+	GateCooldown        Gate = "cooldown"
+	GateFreeze          Gate = "freeze"
+	GateDryRun          Gate = "dry run"
+)
+
+// Verdicts of the samples of a pod
+const (
+	VerdictAbove        = "above"
+	VerdictBelow        = "below"
+	VerdictWithin       = "within"
+	VerdictInsufficient = "insufficient"
+)
+
+// PodExplanation are the samples of a pod and how they compare to the thresholds
+type PodExplanation struct {
+	Name    string
+	Samples []int
+	// Verdict is VerdictAbove when every sample reaches the scale up threshold, VerdictBelow when
+	// none exceeds the scale down threshold, VerdictInsufficient when there are fewer samples
+	// than evaluations and VerdictWithin otherwise
+	Verdict string
+}
+
+// Explanation is the outcome of a single evaluation of a Scaler
+type Explanation struct {
+	Target          string
+	CurrentReplicas int32
+	Selector        string
+	Evaluations     int32
+	// MinReplicas, MaxReplicas and the thresholds include the overrides and the active schedule
+	MinReplicas        int32
+	MaxReplicas        int32
+	ScaleUpThreshold   int32
+	ScaleDownThreshold int32
+	Pods               []PodExplanation
+	Utilization        replicacalculator.Utilization
+	DesiredReplicas    int32
+	Reason             string
+	// Gate is the check which keeps the desired replicas from being applied. It is empty when
+	// the controller would scale the target.
+	Gate Gate
+	// Notes describe the overrides, schedules and freezes which took part in the evaluation
+	Notes []string
+}
+
+// Explainer evaluates a Scaler the same way the controller does without changing anything
+type Explainer struct {
+	scalerclientset clientset.Interface
+	podLister       replicacalculator.PodLister
+	controller      *Controller
+	// scalingFreezes lists the ScalingFreezes which apply to the Scalers
+	scalingFreezes func() ([]*v1alpha1.ScalingFreeze, error)
+}
+
+// Explainer returns an Explainer which uses the informer caches and the metrics source of the
+// controller
+func (c *Controller) Explainer() *Explainer {
+	return &Explainer{scalerclientset: c.scalerclientset, podLister: c.podLister, controller: c,
+		scalingFreezes: c.scalingFreezes}
+}
+
+// NewExplainer returns an Explainer which reads the pods from the API server and the metrics
+// from the metrics source
+func NewExplainer(kubeclientset kubernetes.Interface, scalerclientset clientset.Interface,
+	scaleNamespacer scaleclient.ScalesGetter, mapper apimeta.RESTMapper,
+	metricsSource replicacalculator.MetricsSource) *Explainer {
+	podLister := replicacalculator.NewClientPodLister(kubeclientset)
+	c := &Controller{
+		kubeclientset:   kubeclientset,
+		scalerclientset: scalerclientset,
+		mapper:          mapper,
+		scaleNamespacer: scaleNamespacer,
+		podLister:       podLister,
+		replicaCalc:     replicacalculator.NewReplicaCalculator(podLister, metricsSource),
+	}
+	if activation, ok := metricsSource.(replicacalculator.ActivationSource); ok {
+		c.activation = activation
+	}
+	e := &Explainer{scalerclientset: scalerclientset, podLister: podLister, controller: c}
+	e.scalingFreezes = e.listScalingFreezes
+	return e
+}
+
+// Explain evaluates the Scaler once. The gates which depend on the state of the controller,
+// like conflicts, rollouts and manual changes, are taken from the conditions it last recorded.
+func (e *Explainer) Explain(namespace, name string) (*Explanation, error) {
+	scaler, err := e.scalerclientset.ArjunnaikV1alpha1().Scalers(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return e.explain(scaler)
+}
+
+// ExplainSpec evaluates a Scaler which does not exist yet with the spec in the namespace. Without
+// a status there is no cooldown and no history.
+func (e *Explainer) ExplainSpec(namespace string, spec v1alpha1.ScalerSpec) (*Explanation, error) {
+	if err := ValidateSpec(spec); err != nil {
+		return nil, err
+	}
+	return e.explain(&v1alpha1.Scaler{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}, Spec: spec})
+}
+
+// TargetResource returns the resource of the target, whose scale subresource is read to explain a
+// Scaler
+func (e *Explainer) TargetResource(target v1alpha1.ScaleTarget) (schema.GroupResource, error) {
+	version, err := schema.ParseGroupVersion(target.APIVersion)
+	if err != nil {
+		return schema.GroupResource{}, err
+	}
+	mapping, err := e.controller.mapper.RESTMapping(schema.GroupKind{Group: version.Group, Kind: target.Kind}, version.Version)
+	if err != nil {
+		return schema.GroupResource{}, err
+	}
+	return mapping.Resource.GroupResource(), nil
+}
+
+// ValidateSpec checks what the CRD validation checks when a Scaler is created
+func ValidateSpec(spec v1alpha1.ScalerSpec) error {
+	switch {
+	case spec.Target.Name == "" || spec.Target.Kind == "" || spec.Target.APIVersion == "":
+		return fmt.Errorf("the target needs a name, a kind and an apiVersion")
+	case spec.Evaluations < 1:
+		return fmt.Errorf("evaluations must be at least 1")
+	case spec.MinReplicas < 0 || spec.MaxReplicas < spec.MinReplicas:
+		return fmt.Errorf("the replicas must satisfy 0 <= minReplicas <= maxReplicas")
+	case spec.ScaleDown < 0 || spec.ScaleUp <= spec.ScaleDown:
+		return fmt.Errorf("the thresholds must satisfy 0 <= scaleDown < scaleUp")
+	case spec.ExternalScaler != nil && spec.ExternalScaler.Address == "":
+		return fmt.Errorf("the external scaler needs an address")
+	}
+	if spec.Predictive != nil {
+		return validatePredictive(spec.Predictive)
+	}
+	return nil
+}
+
+func (e *Explainer) explain(scaler *v1alpha1.Scaler) (*Explanation, error) {
+	now := time.Now()
+	x := &Explanation{
+		Target:      describeTarget(scaler),
+		Evaluations: scaler.Spec.Evaluations,
+	}
+
+	if disabled, _ := isDisabled(scaler); disabled {
+		x.Gate = GateDisabled
+		x.Reason = fmt.Sprintf("the annotation %s is set", v1alpha1.DisabledAnnotation)
+		return x, nil
+	}
+	if gate, message := conditionGate(scaler, v1alpha1.ScalerConflicting, v1alpha1.ScalerRolloutInProgress); gate != "" {
+		x.Gate = gate
+		x.Reason = message
+		return x, nil
+	}
+
+	o := parseOverrides(scaler.Annotations, now)
+	for annotation, err := range o.invalid {
+		x.Notes = append(x.Notes, fmt.Sprintf("ignoring annotation %s: %v", annotation, err))
+	}
+	if o.paused {
+		x.Gate = GatePaused
+		x.Reason = fmt.Sprintf("the annotation %s is set", v1alpha1.PausedAnnotation)
+		return x, nil
+	}
+	o.schedule, _, _ = activeSchedule(scaler.Spec.Schedules, now)
+	if o.schedule != nil {
+		x.Notes = append(x.Notes, fmt.Sprintf("schedule %s is active", o.schedule.Name))
+	}
+	for _, override := range o.active {
+		if override.Type != v1alpha1.OverridePaused && override.Type != v1alpha1.OverridePinReplicas {
+			x.Notes = append(x.Notes, fmt.Sprintf("override %s is active", override.Type))
+		}
+	}
+	x.MinReplicas = o.minReplicas(scaler)
+	x.MaxReplicas = o.maxReplicas(scaler)
+	x.ScaleUpThreshold = o.scaleUpThreshold(scaler)
+	x.ScaleDownThreshold = o.scaleDownThreshold(scaler)
+
+	c := e.controller
+	if scaler.Spec.ExternalScaler != nil {
+		// the samples of the controller are only taken by its evaluations, so an explanation
+		// asks the external scaler over a connection of its own and has a single sample
+		explaining := *e.controller
+		explaining.externalSources = newExternalSources()
+		defer explaining.externalSources.forget(scalerKey(scaler))
+		c = &explaining
+		x.Notes = append(x.Notes, "the external scaler is sampled once, without the samples of the controller")
+	}
+
+	scale, _, err := c.targetScale(scaler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the scale of %s: %v", x.Target, err)
+	}
+	x.CurrentReplicas = scale.Spec.Replicas
+	x.Selector = scale.Status.Selector
+
+	freezes, _, invalid := e.activeFreezes(scaler, now)
+	for source, err := range invalid {
+		x.Notes = append(x.Notes, fmt.Sprintf("ignoring %s: %v", source, err))
+	}
+	if o.pin != nil {
+		x.Gate = GatePinned
+		x.DesiredReplicas = *o.pin
+		x.Reason = fmt.Sprintf("the annotation %s holds the target at %d replicas", v1alpha1.PinReplicasAnnotation, *o.pin)
+		d := decision{currentReplicas: x.CurrentReplicas, desiredReplicas: *o.pin}
+		if f := blockingFreeze(freezes, d); f != nil && d.scale() {
+			x.Gate = GateFreeze
+			x.Reason = f.String()
+		}
+		return x, nil
+	}
+	if scale.Spec.Replicas == 0 && scaler.Spec.ScaleToZero == nil {
+		x.Gate = GateZeroReplicas
+		x.Reason = "the target has no replicas and the Scaler cannot scale from zero"
+		return x, nil
+	}
+	if gate, message := conditionGate(scaler, v1alpha1.ScalerManualOverride); gate != "" {
+		x.Gate = gate
+		x.Reason = message
+		return x, nil
+	}
+
+	a, err := c.currentActivation(scaler, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run the activation query: %v", err)
+	}
+	if a.enabled {
+		x.Notes = append(x.Notes, fmt.Sprintf("activation value is %g, in use: %t, idle: %t", a.value, a.inUse, a.idle))
+	}
+
+	metricsNeeded := needsMetrics(scaler, scale, o, a)
+	if metricsNeeded && scale.Status.Selector == "" {
+		x.Gate = GateMissingSelector
+		x.Reason = "the scale of the target has no selector to find its pods"
+		return x, nil
+	}
+
+	d, err := c.decide(scaler, scale, o, a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the replicas: %v", err)
+	}
+	if metricsNeeded {
+		if x.Pods, err = e.explainPods(scaler.Namespace, scale.Status.Selector, d.podMetrics, x); err != nil {
+			return nil, err
+		}
+	}
+	d = applyForecast(d, scaler, scaler.Status.Forecast)
+	x.DesiredReplicas = d.desiredReplicas
+	x.Reason = d.reason
+	x.Utilization = d.utilization
+
+	x.Gate = decisionGate(d, c.dryRun(scaler), freezes)
+	if x.Gate == GateFreeze {
+		x.Reason = blockingFreeze(freezes, d).String()
+	}
+	return x, nil
+}
+
+// conditionGate returns the gate of the first of the conditions which is true
+func conditionGate(scaler *v1alpha1.Scaler, conditionTypes ...v1alpha1.ScalerConditionType) (Gate, string) {
+	gates := map[v1alpha1.ScalerConditionType]Gate{
+		v1alpha1.ScalerConflicting:       GateConflict,
+		v1alpha1.ScalerRolloutInProgress: GateRollout,
+		v1alpha1.ScalerManualOverride:    GateManualOverride,
+	}
+	for _, conditionType := range conditionTypes {
+		if isConditionTrue(&scaler.Status, conditionType) {
+			return gates[conditionType], getCondition(&scaler.Status, conditionType).Message
+		}
+	}
+	return "", ""
+}
+
+// needsMetrics returns false when the decision is made from the activation query alone
+func needsMetrics(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale, o overrides, a activation) bool {
+	if scale.Spec.Replicas == 0 && a.enabled {
+		return false
+	}
+	return !(a.idle && o.minReplicas(scaler) == 0)
+}
+
+// decisionGate returns the gate which keeps the decision from being applied. dryRun is true when
+// either the controller or the Scaler runs in dry run mode.
+func decisionGate(d decision, dryRun bool, freezes []freeze) Gate {
+	switch {
+	case d.atBound:
+		return GateBounds
+	case d.cooldown:
+		return GateCooldown
+	case d.blocked && d.desiredReplicas < 1:
+		return GateIdle
+	case d.desiredReplicas == d.currentReplicas:
+		return GateEquality
+	case blockingFreeze(freezes, d) != nil:
+		return GateFreeze
+	case dryRun:
+		return GateDryRun
+	}
+	return ""
+}
+
+// explainPods lists the pods of the target and compares their samples to the thresholds. Pods
+// which were created after the evaluation have no samples.
+func (e *Explainer) explainPods(namespace, selectorValue string, metrics map[string][]int, x *Explanation) ([]PodExplanation, error) {
+	selector, err := labels.Parse(selectorValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %v", selectorValue, err)
+	}
+	pods, err := e.podLister.List(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(pods))
+	for i, p := range pods {
+		names[i] = p.Name
+	}
+	sort.Strings(names)
+	result := make([]PodExplanation, len(names))
+	for i, name := range names {
+		result[i] = PodExplanation{
+			Name:    name,
+			Samples: metrics[name],
+			Verdict: podVerdict(metrics[name], x.Evaluations, x.ScaleUpThreshold, x.ScaleDownThreshold),
+		}
+	}
+	return result, nil
+}
+
+// podVerdict compares the samples of a pod to the thresholds the way the replica calculator does
+func podVerdict(samples []int, evaluations, scaleUpThreshold, scaleDownThreshold int32) string {
+	if len(samples) < int(evaluations) {
+		return VerdictInsufficient
+	}
+	above, below := true, true
+	for _, s := range samples {
+		if s < int(scaleUpThreshold) {
+			above = false
+		}
+		if s > int(scaleDownThreshold) {
+			below = false
+		}
+	}
+	switch {
+	case above:
+		return VerdictAbove
+	case below:
+		return VerdictBelow
+	}
+	return VerdictWithin
+}
+
+// activeFreezes returns the freezes which apply to the Scaler right now
+func (e *Explainer) activeFreezes(scaler *v1alpha1.Scaler, now time.Time) ([]freeze, time.Time, map[string]error) {
+	scalingFreezes, err := e.scalingFreezes()
+	if err != nil {
+		return nil, time.Time{}, map[string]error{"ScalingFreezes": err}
+	}
+	return activeFreezes(scaler, scalingFreezes, now)
+}
+
+// listScalingFreezes reads the ScalingFreezes from the API server
+func (e *Explainer) listScalingFreezes() ([]*v1alpha1.ScalingFreeze, error) {
+	list, err := e.scalerclientset.ArjunnaikV1alpha1().ScalingFreezes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	scalingFreezes := make([]*v1alpha1.ScalingFreeze, len(list.Items))
+	for i := range list.Items {
+		scalingFreezes[i] = &list.Items[i]
+	}
+	return scalingFreezes, nil
+}
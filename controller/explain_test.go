@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPodVerdict(t *testing.T) {
+	testCases := []struct {
+		name     string
+		samples  []int
+		expected string
+	}{
+		{name: "all samples above", samples: []int{80, 75, 90}, expected: VerdictAbove},
+		{name: "on the scale up threshold", samples: []int{70, 70, 70}, expected: VerdictAbove},
+		{name: "all samples below", samples: []int{5, 20, 0}, expected: VerdictBelow},
+		{name: "one sample in between", samples: []int{80, 50, 90}, expected: VerdictWithin},
+		{name: "too few samples", samples: []int{80, 90}, expected: VerdictInsufficient},
+		{name: "no samples", expected: VerdictInsufficient},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, podVerdict(c.samples, 3, 70, 20))
+		})
+	}
+}
+
+func TestDecisionGate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		d        decision
+		dryRun   bool
+		freezes  []freeze
+		expected Gate
+	}{
+		{name: "scales", d: decision{currentReplicas: 2, desiredReplicas: 3}, expected: ""},
+		{name: "above the maximum", d: decision{currentReplicas: 10, desiredReplicas: 11, blocked: true, atBound: true},
+			expected: GateBounds},
+		{name: "not idle yet", d: decision{currentReplicas: 1, desiredReplicas: 0, blocked: true}, expected: GateIdle},
+		{name: "in cooldown", d: decision{currentReplicas: 2, desiredReplicas: 3, blocked: true, cooldown: true},
+			expected: GateCooldown},
+		{name: "idle in cooldown", d: decision{currentReplicas: 2, desiredReplicas: 0, blocked: true, cooldown: true},
+			expected: GateCooldown},
+		{name: "nothing to do", d: decision{currentReplicas: 2, desiredReplicas: 2}, expected: GateEquality},
+		{name: "frozen", d: decision{currentReplicas: 2, desiredReplicas: 3},
+			freezes: []freeze{{source: "ScalingFreeze release", direction: v1alpha1.FreezeUp}}, expected: GateFreeze},
+		{name: "frozen in the other direction", d: decision{currentReplicas: 2, desiredReplicas: 3},
+			freezes: []freeze{{source: "ScalingFreeze release", direction: v1alpha1.FreezeDown}}, expected: ""},
+		{name: "dry run", d: decision{currentReplicas: 2, desiredReplicas: 3}, dryRun: true, expected: GateDryRun},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, decisionGate(c.d, c.dryRun, c.freezes))
+		})
+	}
+}
+
+func TestDecisionGateDryRun(t *testing.T) {
+	d := decision{currentReplicas: 2, desiredReplicas: 3}
+	testCases := []struct {
+		name     string
+		options  Options
+		dryRun   bool
+		expected Gate
+	}{
+		{name: "applied", expected: ""},
+		{name: "scaler in dry run", dryRun: true, expected: GateDryRun},
+		{name: "controller in dry run", options: Options{DryRun: true}, expected: GateDryRun},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := &Controller{options: c.options}
+			scaler := &v1alpha1.Scaler{Spec: v1alpha1.ScalerSpec{DryRun: c.dryRun}}
+			assert.Equal(t, c.expected, decisionGate(d, controller.dryRun(scaler), nil))
+		})
+	}
+}
+
+func TestValidateSpec(t *testing.T) {
+	valid := func() v1alpha1.ScalerSpec {
+		return v1alpha1.ScalerSpec{
+			Target:      v1alpha1.ScaleTarget{Kind: "Deployment", Name: "web", APIVersion: "apps/v1"},
+			Evaluations: 2, MinReplicas: 1, MaxReplicas: 5, ScaleUp: 70, ScaleDown: 20,
+		}
+	}
+	testCases := []struct {
+		name   string
+		modify func(*v1alpha1.ScalerSpec)
+		valid  bool
+	}{
+		{name: "valid", modify: func(*v1alpha1.ScalerSpec) {}, valid: true},
+		{name: "scale to zero", modify: func(s *v1alpha1.ScalerSpec) { s.MinReplicas = 0 }, valid: true},
+		{name: "no target", modify: func(s *v1alpha1.ScalerSpec) { s.Target = v1alpha1.ScaleTarget{} }},
+		{name: "no evaluations", modify: func(s *v1alpha1.ScalerSpec) { s.Evaluations = 0 }},
+		{name: "minimum above the maximum", modify: func(s *v1alpha1.ScalerSpec) { s.MinReplicas = 6 }},
+		{name: "negative minimum", modify: func(s *v1alpha1.ScalerSpec) { s.MinReplicas = -1 }},
+		{name: "thresholds swapped", modify: func(s *v1alpha1.ScalerSpec) { s.ScaleUp, s.ScaleDown = 20, 70 }},
+		{name: "external scaler", modify: func(s *v1alpha1.ScalerSpec) {
+			s.ExternalScaler = &v1alpha1.ExternalScaler{Address: "queue-scaler:6000"}
+		}, valid: true},
+		{name: "external scaler without an address", modify: func(s *v1alpha1.ScalerSpec) {
+			s.ExternalScaler = &v1alpha1.ExternalScaler{}
+		}},
+		{name: "weekly history of one cycle", modify: func(s *v1alpha1.ScalerSpec) {
+			s.Predictive = &v1alpha1.Predictive{Seasonality: v1alpha1.SeasonalityWeekly, HistoryDays: 10}
+		}},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			spec := valid()
+			c.modify(&spec)
+			err := ValidateSpec(spec)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
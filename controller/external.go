@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/externalscaler"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// externalScalerTimeout limits every call to an external scaler
+const externalScalerTimeout = 10 * time.Second
+
+// externalSources keeps the metrics source of every Scaler with an external scaler. The source
+// collects the samples of the window, so it lives as long as the Scaler and is replaced when the
+// external scaler of the Scaler changes.
+type externalSources struct {
+	mu    sync.Mutex
+	byKey map[string]*externalSource
+}
+
+type externalSource struct {
+	spec   v1alpha1.ExternalScaler
+	source *externalscaler.MetricsSource
+}
+
+func newExternalSources() *externalSources {
+	return &externalSources{byKey: map[string]*externalSource{}}
+}
+
+func (e *externalSources) get(scaler *v1alpha1.Scaler) (*externalscaler.MetricsSource, error) {
+	key := scalerKey(scaler)
+	spec := scaler.Spec.ExternalScaler
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	existing, ok := e.byKey[key]
+	if ok && reflect.DeepEqual(existing.spec, *spec) {
+		return existing.source, nil
+	}
+	if ok {
+		existing.source.Close()
+		delete(e.byKey, key)
+	}
+	client, err := externalscaler.NewClient(spec.Address, externalScalerTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address of the external scaler %q: %v", spec.Address, err)
+	}
+	ref := &externalscaler.ScaledObjectRef{Name: scaler.Name, Namespace: scaler.Namespace, ScalerMetadata: spec.Metadata}
+	source := externalscaler.NewMetricsSource(client, ref)
+	e.byKey[key] = &externalSource{spec: *spec.DeepCopy(), source: source}
+	return source, nil
+}
+
+func (e *externalSources) forget(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if existing, ok := e.byKey[key]; ok {
+		existing.source.Close()
+		delete(e.byKey, key)
+	}
+}
+
+// replicaCalculator returns the calculator for the Scaler, which uses its external scaler if it
+// has one
+func (c *Controller) replicaCalculator(scaler *v1alpha1.Scaler) (*replicacalculator.ReplicaCalculator, error) {
+	if scaler.Spec.ExternalScaler == nil {
+		return c.replicaCalc, nil
+	}
+	source, err := c.externalSources.get(scaler)
+	if err != nil {
+		return nil, err
+	}
+	return replicacalculator.NewReplicaCalculator(c.podLister, source), nil
+}
+
+// activationSource returns the source of the activation value of the Scaler. An external scaler
+// tells whether the target is in use with IsActive.
+func (c *Controller) activationSource(scaler *v1alpha1.Scaler) (replicacalculator.ActivationSource, error) {
+	if scaler.Spec.ExternalScaler == nil {
+		return c.activation, nil
+	}
+	source, err := c.externalSources.get(scaler)
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
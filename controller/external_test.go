@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestExternalSources(t *testing.T) {
+	sources := newExternalSources()
+	scaler := &v1alpha1.Scaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "worker"},
+		Spec: v1alpha1.ScalerSpec{ExternalScaler: &v1alpha1.ExternalScaler{
+			Address:  "queue-scaler:6000",
+			Metadata: map[string]string{"queue": "jobs"},
+		}},
+	}
+
+	source, err := sources.get(scaler)
+	assert.NoError(t, err)
+	same, _ := sources.get(scaler.DeepCopy())
+	assert.True(t, source == same, "the source is kept between evaluations")
+
+	changed := scaler.DeepCopy()
+	changed.Spec.ExternalScaler.Metadata["queue"] = "emails"
+	replaced, err := sources.get(changed)
+	assert.NoError(t, err)
+	assert.False(t, source == replaced, "the source is replaced when the external scaler changes")
+	same, _ = sources.get(changed)
+	assert.True(t, replaced == same)
+
+	sources.forget("batch/worker")
+	assert.Len(t, sources.byKey, 0)
+	sources.forget("batch/worker")
+}
+
+func TestSourcesOfScaler(t *testing.T) {
+	c := &Controller{externalSources: newExternalSources()}
+	scaler := &v1alpha1.Scaler{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}}
+	activation, err := c.activationSource(scaler)
+	assert.NoError(t, err)
+	assert.Nil(t, activation)
+	calculator, err := c.replicaCalculator(scaler)
+	assert.NoError(t, err)
+	assert.True(t, calculator == c.replicaCalc)
+
+	scaler.Spec.ExternalScaler = &v1alpha1.ExternalScaler{Address: "queue-scaler:6000"}
+	activation, err = c.activationSource(scaler)
+	assert.NoError(t, err)
+	assert.NotNil(t, activation)
+	calculator, err = c.replicaCalculator(scaler)
+	assert.NoError(t, err)
+	assert.NotNil(t, calculator)
+	assert.Len(t, c.externalSources.byKey, 1)
+}
@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/predict"
+	log "github.com/sirupsen/logrus"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	ErrForecast = "ErrForecast"
+
+	// forecastStep is the resolution of the demand history. A new forecast is only made when
+	// the previous one is older than a step.
+	forecastStep = 5 * time.Minute
+
+	defaultHorizonSeconds = 600
+	defaultMinConfidence  = 50
+)
+
+// forecasts keeps the latest forecast of every predictive Scaler, or the error it failed with,
+// so that the history is not fetched and fitted on every resync
+type forecasts struct {
+	mu    sync.Mutex
+	byKey map[string]forecastResult
+}
+
+type forecastResult struct {
+	time     time.Time
+	forecast *v1alpha1.Forecast
+	err      error
+}
+
+func newForecasts() *forecasts {
+	return &forecasts{byKey: map[string]forecastResult{}}
+}
+
+// get returns the last result of the Scaler if it is not older than a step
+func (f *forecasts) get(key string, now time.Time) (forecastResult, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result, ok := f.byKey[key]
+	if !ok || now.Sub(result.time) >= forecastStep {
+		return forecastResult{}, false
+	}
+	return result, true
+}
+
+func (f *forecasts) set(key string, result forecastResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.byKey[key] = result
+}
+
+func (f *forecasts) forget(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.byKey, key)
+}
+
+// seasonPeriod returns the number of steps in a cycle of the seasonality
+func seasonPeriod(s v1alpha1.Seasonality) (int, error) {
+	switch s {
+	case "", v1alpha1.SeasonalityDaily:
+		return int(24 * time.Hour / forecastStep), nil
+	case v1alpha1.SeasonalityWeekly:
+		return int(7 * 24 * time.Hour / forecastStep), nil
+	}
+	return 0, fmt.Errorf("unknown seasonality %q", s)
+}
+
+// historyDays returns the days of history the model is fitted to. Two cycles are the least a
+// seasonal model can be fitted to, the default leaves room for gaps in the history.
+func historyDays(p *v1alpha1.Predictive) int32 {
+	if p.HistoryDays > 0 {
+		return p.HistoryDays
+	}
+	if p.Seasonality == v1alpha1.SeasonalityWeekly {
+		return 14
+	}
+	return 7
+}
+
+// validatePredictive checks that the history covers at least two cycles of the seasonality
+func validatePredictive(p *v1alpha1.Predictive) error {
+	period, err := seasonPeriod(p.Seasonality)
+	if err != nil {
+		return err
+	}
+	cycleDays := int32(time.Duration(period) * forecastStep / (24 * time.Hour))
+	if days := historyDays(p); days < 2*cycleDays {
+		return fmt.Errorf("historyDays must cover two cycles of %d days but is %d", cycleDays, days)
+	}
+	return nil
+}
+
+// minConfidence returns the confidence in percent a forecast needs to be acted on
+func minConfidence(p *v1alpha1.Predictive) int32 {
+	if p.MinConfidence == nil {
+		return defaultMinConfidence
+	}
+	return *p.MinConfidence
+}
+
+// forecastDemand fits a model to the demand history and returns the highest demand it
+// forecasts within the horizon. Replicas are the ones needed to keep every pod below the scale
+// up threshold.
+func forecastDemand(history []float64, p *v1alpha1.Predictive, scaleUpThreshold int32, now time.Time) (*v1alpha1.Forecast, error) {
+	period, err := seasonPeriod(p.Seasonality)
+	if err != nil {
+		return nil, err
+	}
+	if scaleUpThreshold <= 0 {
+		return nil, fmt.Errorf("the scale up threshold must be positive")
+	}
+	model, err := predict.Fit(history, period)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := int(math.Ceil(float64(horizon(p)) / float64(forecastStep)))
+	peak := 0.0
+	for _, demand := range model.Forecast(steps) {
+		peak = math.Max(peak, demand)
+	}
+	return &v1alpha1.Forecast{
+		Time:       metav1.NewTime(now),
+		Demand:     int32(math.Round(peak)),
+		Replicas:   int32(math.Ceil(peak / float64(scaleUpThreshold))),
+		Confidence: int32(math.Round(model.Confidence() * 100)),
+	}, nil
+}
+
+// syncForecast returns the demand forecast of a predictive Scaler and records it in the status.
+// A forecast which cannot be made is reported but does not stop the reactive scaling. It is not
+// tried again for a step.
+func (c *Controller) syncForecast(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale,
+	o overrides) (*v1alpha1.Forecast, *v1alpha1.Scaler, error) {
+	var err error
+	p := scaler.Spec.Predictive
+	if p == nil {
+		if scaler.Status.Forecast != nil {
+			scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+				status.Forecast = nil
+			})
+		}
+		return nil, scaler, err
+	}
+
+	key := scalerKey(scaler)
+	now := time.Now()
+	if result, ok := c.forecasts.get(key, now); ok {
+		return result.forecast, scaler, nil
+	}
+
+	forecast, err := c.makeForecast(scaler, scale, o, now)
+	c.forecasts.set(key, forecastResult{time: now, forecast: forecast, err: err})
+	if err != nil {
+		log.Warnf("failed to forecast the demand of %s/%s: %v", scaler.Namespace, scaler.Name, err)
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrForecast, "failed to forecast the demand: %v", err)
+		return nil, scaler, nil
+	}
+	log.Infof("forecast for %s/%s: demand %d%% needs %d replicas with %d%% confidence", scaler.Namespace,
+		scaler.Name, forecast.Demand, forecast.Replicas, forecast.Confidence)
+
+	scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		status.Forecast = forecast
+	})
+	return forecast, scaler, err
+}
+
+// makeForecast fetches the demand history of the pods selected by the scale of the target, like
+// the reactive scaling does, and forecasts the demand
+func (c *Controller) makeForecast(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale, o overrides,
+	now time.Time) (*v1alpha1.Forecast, error) {
+	if c.demand == nil {
+		return nil, fmt.Errorf("the metrics source does not provide the demand history")
+	}
+	if err := validatePredictive(scaler.Spec.Predictive); err != nil {
+		return nil, err
+	}
+	if scale.Status.Selector == "" {
+		return nil, fmt.Errorf("the scale of the target has no selector to find its pods")
+	}
+	selector, err := labels.Parse(scale.Status.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %v", scale.Status.Selector, err)
+	}
+	end := now.Truncate(forecastStep)
+	start := end.Add(-time.Duration(historyDays(scaler.Spec.Predictive)) * 24 * time.Hour)
+	history, err := c.demand.GetDemandHistory(scaler.Namespace, selector, start, end, forecastStep)
+	if err != nil {
+		return nil, err
+	}
+	return forecastDemand(history, scaler.Spec.Predictive, o.scaleUpThreshold(scaler), now)
+}
+
+// applyForecast raises the desired replicas of the decision to the ones needed for the forecast
+// demand. The forecast never lowers the replicas so the reactive decision acts as a floor, and
+// while the forecast is confident a reactive scale-down is held at the forecast replicas, so that
+// the target does not flap until the demand arrives.
+func applyForecast(d decision, scaler *v1alpha1.Scaler, forecast *v1alpha1.Forecast) decision {
+	if forecast == nil || forecast.Confidence < minConfidence(scaler.Spec.Predictive) {
+		return d
+	}
+	// a blocked decision stays blocked, and targets at zero are woken up by their activation query
+	if d.blocked || d.currentReplicas == 0 {
+		return d
+	}
+	replicas := forecast.Replicas
+	if replicas > d.maxReplicas {
+		replicas = d.maxReplicas
+	}
+	if replicas <= d.desiredReplicas {
+		return d
+	}
+	d.desiredReplicas = replicas
+	// holding a scale-down does not scale the target any further than the reactive decision did,
+	// so only a scale-up is checked against the cooldown again
+	if replicas > d.currentReplicas {
+		if d = applyCooldown(d, scaler, time.Now()); d.blocked {
+			return d
+		}
+	}
+	d.reason = fmt.Sprintf("forecast demand of %d%% within the next %s with %d%% confidence", forecast.Demand,
+		horizon(scaler.Spec.Predictive), forecast.Confidence)
+	return d
+}
+
+func horizon(p *v1alpha1.Predictive) time.Duration {
+	if p.HorizonSeconds <= 0 {
+		return defaultHorizonSeconds * time.Second
+	}
+	return time.Duration(p.HorizonSeconds) * time.Second
+}
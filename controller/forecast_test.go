@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/client-go/tools/record"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestForecastDemand(t *testing.T) {
+	// three days of a daily cycle between 100% and 500% of a pod, sampled every step
+	period := int(24 * time.Hour / forecastStep)
+	history := make([]float64, 3*period)
+	for i := range history {
+		history[i] = 300 - 200*math.Cos(2*math.Pi*float64(i)/float64(period))
+	}
+
+	now := time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC)
+	forecast, err := forecastDemand(history, &v1alpha1.Predictive{HorizonSeconds: 600}, 80, now)
+	assert.NoError(t, err)
+	require.NotNil(t, forecast)
+	// the history ends at the low point of the cycle, so the demand rises just a little
+	assert.InDelta(t, 101, float64(forecast.Demand), 5)
+	assert.Equal(t, int32(2), forecast.Replicas)
+	assert.True(t, forecast.Confidence > 90, "expected a high confidence but got %d", forecast.Confidence)
+
+	_, err = forecastDemand(history[:period], &v1alpha1.Predictive{}, 80, now)
+	assert.Error(t, err)
+}
+
+func TestApplyForecast(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	scaler := &v1alpha1.Scaler{Spec: v1alpha1.ScalerSpec{Predictive: &v1alpha1.Predictive{MinConfidence: int32Ptr(60)}}}
+
+	testCases := []struct {
+		name     string
+		d        decision
+		forecast *v1alpha1.Forecast
+		desired  int32
+		blocked  bool
+	}{
+		{
+			name:     "scales up ahead of the demand",
+			d:        decision{currentReplicas: 3, desiredReplicas: 3, maxReplicas: 10},
+			forecast: &v1alpha1.Forecast{Replicas: 6, Confidence: 80},
+			desired:  6,
+		},
+		{
+			name:     "ignores a forecast with a low confidence",
+			d:        decision{currentReplicas: 3, desiredReplicas: 3, maxReplicas: 10},
+			forecast: &v1alpha1.Forecast{Replicas: 6, Confidence: 40},
+			desired:  3,
+		},
+		{
+			name:     "reactive decision is the floor",
+			d:        decision{currentReplicas: 3, desiredReplicas: 5, maxReplicas: 10},
+			forecast: &v1alpha1.Forecast{Replicas: 1, Confidence: 90},
+			desired:  5,
+		},
+		{
+			name:     "scale-down is held at the forecast",
+			d:        decision{currentReplicas: 6, desiredReplicas: 5, maxReplicas: 10},
+			forecast: &v1alpha1.Forecast{Replicas: 6, Confidence: 80},
+			desired:  6,
+		},
+		{
+			name:     "scale-down stops at the forecast",
+			d:        decision{currentReplicas: 6, desiredReplicas: 2, maxReplicas: 10},
+			forecast: &v1alpha1.Forecast{Replicas: 4, Confidence: 80},
+			desired:  4,
+		},
+		{
+			name:     "limited by the maximum",
+			d:        decision{currentReplicas: 3, desiredReplicas: 3, maxReplicas: 4},
+			forecast: &v1alpha1.Forecast{Replicas: 9, Confidence: 90},
+			desired:  4,
+		},
+		{
+			name:     "blocked decision stays blocked",
+			d:        decision{currentReplicas: 3, desiredReplicas: 2, maxReplicas: 10, blocked: true},
+			forecast: &v1alpha1.Forecast{Replicas: 6, Confidence: 90},
+			desired:  2,
+			blocked:  true,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			d := applyForecast(c.d, scaler, c.forecast)
+			assert.Equal(t, c.desired, d.desiredReplicas)
+			assert.Equal(t, c.blocked, d.blocked)
+		})
+	}
+}
+
+func TestValidatePredictive(t *testing.T) {
+	testCases := []struct {
+		name       string
+		predictive v1alpha1.Predictive
+		days       int32
+		valid      bool
+	}{
+		{name: "daily default", predictive: v1alpha1.Predictive{}, days: 7, valid: true},
+		{name: "weekly default", predictive: v1alpha1.Predictive{Seasonality: v1alpha1.SeasonalityWeekly}, days: 14, valid: true},
+		{name: "two days", predictive: v1alpha1.Predictive{HistoryDays: 2}, days: 2, valid: true},
+		{name: "one day", predictive: v1alpha1.Predictive{HistoryDays: 1}, days: 1},
+		{name: "one week", predictive: v1alpha1.Predictive{Seasonality: v1alpha1.SeasonalityWeekly, HistoryDays: 7}, days: 7},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.days, historyDays(&c.predictive))
+			err := validatePredictive(&c.predictive)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestSyncForecastCachesFailures(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Controller{recorder: recorder, forecasts: newForecasts()}
+	scaler := &v1alpha1.Scaler{Spec: v1alpha1.ScalerSpec{Predictive: &v1alpha1.Predictive{}}}
+	scaler.Namespace, scaler.Name = "default", "web"
+	scale := &autoscalingv1.Scale{Status: autoscalingv1.ScaleStatus{Selector: "app=web"}}
+
+	for i := 0; i < 3; i++ {
+		forecast, _, err := c.syncForecast(scaler, scale, overrides{})
+		assert.NoError(t, err)
+		assert.Nil(t, forecast)
+	}
+	assert.Len(t, recorder.Events, 1, "a failed forecast is not tried again within a step")
+}
@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"strings"
+	"time"
+)
+
+const (
+	ScalingFrozen     = "ScalingFrozen"
+	ScalingUnfrozen   = "ScalingUnfrozen"
+	ScalingSuppressed = "ScalingSuppressed"
+	ErrInvalidFreeze  = "ErrInvalidFreeze"
+
+	// freezeBoundary is the topic of the next time a window opens or closes the Scaler was queued for
+	freezeBoundary = "freeze-boundary"
+)
+
+// freeze is an active blackout window of a Scaler or an active ScalingFreeze
+type freeze struct {
+	// source describes where the freeze comes from, e.g. "ScalingFreeze release"
+	source    string
+	direction v1alpha1.FreezeDirection
+	reason    string
+}
+
+// blocks returns true if the freeze forbids scaling in the direction of the decision
+func (f freeze) blocks(d decision) bool {
+	switch f.direction {
+	case v1alpha1.FreezeUp:
+		return d.desiredReplicas > d.currentReplicas
+	case v1alpha1.FreezeDown:
+		return d.desiredReplicas < d.currentReplicas
+	}
+	return true
+}
+
+func (f freeze) String() string {
+	direction := f.direction
+	if direction == "" {
+		direction = v1alpha1.FreezeAll
+	}
+	if f.reason == "" {
+		return fmt.Sprintf("%s blocks %s", f.source, direction)
+	}
+	return fmt.Sprintf("%s blocks %s: %s", f.source, direction, f.reason)
+}
+
+// freezeWindowActive returns true if the window is open at the given time and the next time at
+// which it opens or closes. A window with a cron expression is only open during the occurrences
+// which fall between Start and End.
+func freezeWindowActive(w v1alpha1.FreezeWindow, now time.Time) (bool, time.Time, error) {
+	if w.Start == nil && w.End == nil && w.Cron == "" {
+		return false, time.Time{}, fmt.Errorf("either a time range or a cron expression is required")
+	}
+	switch w.Direction {
+	case "", v1alpha1.FreezeUp, v1alpha1.FreezeDown, v1alpha1.FreezeAll:
+	default:
+		return false, time.Time{}, fmt.Errorf("unknown direction %q", w.Direction)
+	}
+
+	var boundary time.Time
+	earliest := func(t time.Time) {
+		if !t.IsZero() && t.After(now) && (boundary.IsZero() || t.Before(boundary)) {
+			boundary = t
+		}
+	}
+
+	inRange := true
+	if w.Start != nil {
+		earliest(w.Start.Time)
+		inRange = !now.Before(w.Start.Time)
+	}
+	if w.End != nil {
+		earliest(w.End.Time)
+		inRange = inRange && now.Before(w.End.Time)
+	}
+	if w.Cron == "" {
+		return inRange, boundary, nil
+	}
+
+	window, err := scheduleWindow(v1alpha1.Schedule{Cron: w.Cron, TimeZone: w.TimeZone, DurationSeconds: w.DurationSeconds})
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	active, _ := window.Active(now)
+	earliest(window.NextBoundary(now))
+	return inRange && active, boundary, nil
+}
+
+// freezeApplies returns true if the ScalingFreeze selects the Scaler
+func freezeApplies(f *v1alpha1.ScalingFreeze, scaler *v1alpha1.Scaler) (bool, error) {
+	if len(f.Spec.Namespaces) > 0 {
+		found := false
+		for _, ns := range f.Spec.Namespaces {
+			if ns == scaler.Namespace {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	if f.Spec.Selector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(f.Spec.Selector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(scaler.Labels)), nil
+}
+
+// activeFreezes returns the blackout windows of the Scaler and the ScalingFreezes selecting it
+// which are active at the given time, and the next time at which any of them opens or closes.
+// Windows which cannot be evaluated are returned by source with the error.
+func activeFreezes(scaler *v1alpha1.Scaler, freezes []*v1alpha1.ScalingFreeze, now time.Time) ([]freeze, time.Time, map[string]error) {
+	var (
+		active   []freeze
+		boundary time.Time
+		invalid  = map[string]error{}
+	)
+	check := func(source string, w v1alpha1.FreezeWindow) {
+		open, next, err := freezeWindowActive(w, now)
+		if err != nil {
+			invalid[source] = err
+			return
+		}
+		if open {
+			active = append(active, freeze{source: source, direction: w.Direction, reason: w.Reason})
+		}
+		if !next.IsZero() && (boundary.IsZero() || next.Before(boundary)) {
+			boundary = next
+		}
+	}
+
+	for i, w := range scaler.Spec.BlackoutWindows {
+		name := w.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i)
+		}
+		check("blackout window "+name, w)
+	}
+	for _, f := range freezes {
+		source := "ScalingFreeze " + f.Name
+		applies, err := freezeApplies(f, scaler)
+		if err != nil {
+			invalid[source] = err
+			continue
+		}
+		if applies {
+			check(source, f.Spec.FreezeWindow)
+		}
+	}
+	return active, boundary, invalid
+}
+
+// blockingFreeze returns the first freeze which forbids the decision or nil
+func blockingFreeze(freezes []freeze, d decision) *freeze {
+	for i := range freezes {
+		if freezes[i].blocks(d) {
+			return &freezes[i]
+		}
+	}
+	return nil
+}
+
+// suppressFrozen blocks a decision which one of the freezes forbids. ScalingSuppressed is only
+// emitted when the suppressed decision changes.
+func (c *Controller) suppressFrozen(scaler *v1alpha1.Scaler, d decision, freezes []freeze) decision {
+	key := scalerKey(scaler)
+	f := blockingFreeze(freezes, d)
+	if f == nil || !d.scale() {
+		c.reported.changed(key, ScalingSuppressed, "")
+		return d
+	}
+	d.blocked = true
+	d.reason = f.String()
+	if c.reported.changed(key, ScalingSuppressed, fmt.Sprintf("%d %d %s", d.currentReplicas, d.desiredReplicas, d.reason)) {
+		log.Infof("not scaling %s/%s from %d to %d replicas: %s", scaler.Namespace, scaler.Name,
+			d.currentReplicas, d.desiredReplicas, d.reason)
+		c.recorder.Eventf(scaler, corev1.EventTypeNormal, ScalingSuppressed, "not scaling from %d to %d replicas: %s",
+			d.currentReplicas, d.desiredReplicas, d.reason)
+	}
+	return d
+}
+
+// syncFreezes returns the active freezes of the Scaler and reflects them in the Frozen
+// condition. The Scaler is queued again for the next time a window opens or closes.
+func (c *Controller) syncFreezes(scaler *v1alpha1.Scaler) ([]freeze, *v1alpha1.Scaler, error) {
+	scalingFreezes, err := c.scalingFreezes()
+	if err != nil {
+		return nil, scaler, err
+	}
+	if len(scaler.Spec.BlackoutWindows) == 0 && len(scalingFreezes) == 0 &&
+		!isConditionTrue(&scaler.Status, v1alpha1.ScalerFrozen) {
+		return nil, scaler, nil
+	}
+
+	key := scalerKey(scaler)
+	active, boundary, invalid := activeFreezes(scaler, scalingFreezes, time.Now())
+	if c.reported.changed(key, ErrInvalidFreeze, errorsValue(invalid)) {
+		for source, err := range invalid {
+			c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrInvalidFreeze, "ignoring %s: %v", source, err)
+		}
+	}
+	if !boundary.IsZero() && c.reported.changed(key, freezeBoundary, boundary.Format(time.RFC3339)) {
+		c.queue.AddAfter(key, time.Until(boundary))
+	}
+
+	if len(active) == 0 {
+		if !isConditionTrue(&scaler.Status, v1alpha1.ScalerFrozen) {
+			return nil, scaler, nil
+		}
+		log.Infof("scaling of %s/%s is no longer frozen", scaler.Namespace, scaler.Name)
+		scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+			setCondition(status, v1alpha1.ScalerFrozen, corev1.ConditionFalse, ScalingUnfrozen, "no freeze is active")
+		})
+		return nil, scaler, err
+	}
+
+	descriptions := make([]string, 0, len(active))
+	for _, f := range active {
+		descriptions = append(descriptions, f.String())
+	}
+	message := strings.Join(descriptions, "; ")
+	if !conditionChanged(&scaler.Status, v1alpha1.ScalerFrozen, corev1.ConditionTrue, ScalingFrozen, message) {
+		return active, scaler, nil
+	}
+	log.Infof("scaling of %s/%s is frozen: %s", scaler.Namespace, scaler.Name, message)
+	scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		setCondition(status, v1alpha1.ScalerFrozen, corev1.ConditionTrue, ScalingFrozen, message)
+	})
+	return active, scaler, err
+}
+
+// scalingFreezes returns all the ScalingFreezes, or none when they are not watched
+func (c *Controller) scalingFreezes() ([]*v1alpha1.ScalingFreeze, error) {
+	if c.freezesLister == nil {
+		return nil, nil
+	}
+	return c.freezesLister.List(labels.Everything())
+}
+
+// enqueueScalersForFreeze queues every Scaler when a ScalingFreeze changes. Freezes can select
+// Scalers in any namespace so working out the affected ones is not worth it.
+func (c *Controller) enqueueScalersForFreeze(obj interface{}) {
+	c.enqueueAllScalers()
+}
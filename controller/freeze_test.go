@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"testing"
+	"time"
+)
+
+func TestActiveFreezes(t *testing.T) {
+	now := time.Date(2019, 11, 29, 12, 0, 0, 0, time.UTC)
+	timePtr := func(t time.Time) *metav1.Time { return &metav1.Time{Time: t} }
+
+	scaler := &v1alpha1.Scaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web", Labels: map[string]string{"tier": "frontend"}},
+		Spec: v1alpha1.ScalerSpec{BlackoutWindows: []v1alpha1.FreezeWindow{
+			{Name: "sale", Start: timePtr(now.Add(-time.Hour)), End: timePtr(now.Add(2 * time.Hour)),
+				Direction: v1alpha1.FreezeDown, Reason: "black friday"},
+			{Name: "future", Start: timePtr(now.Add(time.Hour))},
+			{Name: "empty"},
+		}},
+	}
+	freezes := []*v1alpha1.ScalingFreeze{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "release"},
+			Spec: v1alpha1.ScalingFreezeSpec{
+				FreezeWindow: v1alpha1.FreezeWindow{Cron: "0 11 * * fri", DurationSeconds: 7200},
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "frontend"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-namespace"},
+			Spec: v1alpha1.ScalingFreezeSpec{
+				FreezeWindow: v1alpha1.FreezeWindow{Start: timePtr(now.Add(-time.Hour))},
+				Namespaces:   []string{"payments"},
+			},
+		},
+	}
+
+	active, boundary, invalid := activeFreezes(scaler, freezes, now)
+	assert.Len(t, active, 2)
+	if len(active) == 2 {
+		assert.Equal(t, "blackout window sale", active[0].source)
+		assert.Equal(t, "ScalingFreeze release", active[1].source)
+	}
+	assert.True(t, now.Add(time.Hour).Equal(boundary), "expected boundary %s but got %s", now.Add(time.Hour), boundary)
+	assert.Len(t, invalid, 1)
+	assert.NotNil(t, invalid["blackout window empty"])
+}
+
+func TestBlockingFreeze(t *testing.T) {
+	down := freeze{source: "blackout window sale", direction: v1alpha1.FreezeDown}
+	up := freeze{source: "blackout window budget", direction: v1alpha1.FreezeUp}
+	all := freeze{source: "ScalingFreeze release"}
+
+	testCases := []struct {
+		name     string
+		freezes  []freeze
+		from, to int32
+		blocking string
+	}{
+		{name: "scale down during a down freeze", freezes: []freeze{down}, from: 5, to: 3, blocking: down.source},
+		{name: "scale up during a down freeze", freezes: []freeze{down}, from: 5, to: 8},
+		{name: "scale up during an up freeze", freezes: []freeze{down, up}, from: 5, to: 8, blocking: up.source},
+		{name: "all directions", freezes: []freeze{all}, from: 5, to: 3, blocking: all.source},
+		{name: "no freezes", from: 5, to: 3},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			f := blockingFreeze(c.freezes, decision{currentReplicas: c.from, desiredReplicas: c.to})
+			if c.blocking == "" {
+				assert.Nil(t, f)
+				return
+			}
+			assert.NotNil(t, f)
+			if f != nil {
+				assert.Equal(t, c.blocking, f.source)
+			}
+		})
+	}
+}
+
+func TestSuppressFrozen(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Controller{recorder: recorder, reported: newReported()}
+	scaler := &v1alpha1.Scaler{}
+	scaler.Namespace, scaler.Name = "default", "web"
+	freezes := []freeze{{source: "ScalingFreeze release"}}
+
+	for i := 0; i < 3; i++ {
+		d := c.suppressFrozen(scaler, decision{currentReplicas: 5, desiredReplicas: 8}, freezes)
+		assert.True(t, d.blocked)
+		assert.Equal(t, "ScalingFreeze release blocks All", d.reason, "the freeze is the reason of the recommendation")
+	}
+	assert.Len(t, recorder.Events, 1, "a repeated decision is suppressed quietly")
+
+	d := c.suppressFrozen(scaler, decision{currentReplicas: 5, desiredReplicas: 5}, freezes)
+	assert.False(t, d.blocked)
+	c.suppressFrozen(scaler, decision{currentReplicas: 5, desiredReplicas: 8}, freezes)
+	assert.Len(t, recorder.Events, 2)
+
+	// the scale client is not set so the test fails if the pin is applied
+	scale := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 5}}
+	assert.NoError(t, c.applyPin(scaler, scale, schema.GroupResource{}, 2, freezes))
+	assert.Len(t, recorder.Events, 3, "the pin is suppressed by the freeze")
+}
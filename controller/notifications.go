@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/notify"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sync"
+	"time"
+)
+
+const (
+	// failureNotificationThreshold is the number of consecutive failed reconciliations after
+	// which a Failure notification is sent
+	failureNotificationThreshold = 3
+	// secretTTL is how long a notification secret is reused before it is read again
+	secretTTL = time.Minute
+
+	ErrNotification = "ErrNotification"
+)
+
+// notificationState remembers what was already notified so that a Scaler which stays at a bound
+// or keeps failing does not send a notification on every resync
+type notificationState struct {
+	mu       sync.Mutex
+	failures map[string]int
+	atBound  map[string]bool
+}
+
+func newNotificationState() *notificationState {
+	return &notificationState{failures: map[string]int{}, atBound: map[string]bool{}}
+}
+
+// failed counts a failure and returns the number of consecutive failures
+func (s *notificationState) failed(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[key]++
+	return s.failures[key]
+}
+
+func (s *notificationState) succeeded(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+}
+
+// reachedBound records whether the Scaler is held at a bound and returns true when it just
+// reached it
+func (s *notificationState) reachedBound(key string, atBound bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reached := atBound && !s.atBound[key]
+	if atBound {
+		s.atBound[key] = true
+	} else {
+		delete(s.atBound, key)
+	}
+	return reached
+}
+
+func (s *notificationState) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+	delete(s.atBound, key)
+}
+
+// trackFailures sends a Failure notification when the Scaler failed a number of times in a row
+func (c *Controller) trackFailures(scaler *v1alpha1.Scaler, err error) {
+	key := scalerKey(scaler)
+	if err == nil {
+		c.notifications.succeeded(key)
+		return
+	}
+	if failures := c.notifications.failed(key); failures == failureNotificationThreshold {
+		c.notify(scaler, notify.Event{
+			Type:     string(v1alpha1.NotifyFailure),
+			Reason:   err.Error(),
+			Failures: failures,
+		})
+	}
+}
+
+// notifyDecision sends the notifications for a decision which was applied or blocked by a bound
+func (c *Controller) notifyDecision(scaler *v1alpha1.Scaler, d decision) {
+	if !c.notifications.reachedBound(scalerKey(scaler), d.atBound) && !d.scale() {
+		return
+	}
+	eventType := v1alpha1.NotifyBoundReached
+	if d.scale() {
+		eventType = v1alpha1.NotifyScaledUp
+		if d.desiredReplicas < d.currentReplicas {
+			eventType = v1alpha1.NotifyScaledDown
+		}
+	}
+	c.notify(scaler, notify.Event{
+		Type:         string(eventType),
+		FromReplicas: d.currentReplicas,
+		ToReplicas:   d.desiredReplicas,
+		Reason:       d.reason,
+	})
+}
+
+// notify queues the event for every notification endpoint of the Scaler which wants it
+func (c *Controller) notify(scaler *v1alpha1.Scaler, event notify.Event) {
+	if c.options.Notifier == nil {
+		return
+	}
+	event.Namespace = scaler.Namespace
+	event.Scaler = scaler.Name
+	event.Target = fmt.Sprintf("%s/%s", scaler.Spec.Target.Kind, scaler.Spec.Target.Name)
+	event.Time = time.Now().UTC()
+
+	for _, n := range scaler.Spec.Notifications {
+		if !wantsEvent(n, v1alpha1.NotificationEvent(event.Type)) {
+			continue
+		}
+		message, err := c.notificationMessage(scaler, n, event)
+		if err != nil {
+			log.Errorf("failed to prepare notification for %s/%s: %v", scaler.Namespace, scaler.Name, err)
+			c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrNotification, "failed to notify %s: %v", n.URL, err)
+			continue
+		}
+		c.options.Notifier.Notify(message)
+	}
+}
+
+func (c *Controller) notificationMessage(scaler *v1alpha1.Scaler, n v1alpha1.Notification,
+	event notify.Event) (notify.Message, error) {
+	body, err := notify.Render(n.Template, event)
+	if err != nil {
+		return notify.Message{}, fmt.Errorf("invalid template: %v", err)
+	}
+	message := notify.Message{URL: n.URL, Event: event, Body: body}
+	if n.SecretRef == nil {
+		return message, nil
+	}
+	data, err := c.secrets.get(scaler.Namespace, n.SecretRef.Name)
+	if err != nil {
+		return message, err
+	}
+	key, ok := data[n.SecretRef.Key]
+	if !ok {
+		return message, fmt.Errorf("secret %s has no key %s", n.SecretRef.Name, n.SecretRef.Key)
+	}
+	message.Key = key
+	return message, nil
+}
+
+// secretCache reads the secrets of the notifications one by one and keeps them for secretTTL, so
+// that a burst of notifications does not read the same secret over and over while the controller
+// does not need to watch all the secrets. A rotated key is used after secretTTL at the latest.
+type secretCache struct {
+	client  kubernetes.Interface
+	now     func() time.Time
+	mu      sync.Mutex
+	secrets map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	data map[string][]byte
+	read time.Time
+}
+
+func newSecretCache(client kubernetes.Interface) *secretCache {
+	return &secretCache{client: client, now: time.Now, secrets: map[string]cachedSecret{}}
+}
+
+// get returns the data of the secret
+func (s *secretCache) get(namespace, name string) (map[string][]byte, error) {
+	key := namespace + "/" + name
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.secrets[key]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.read) < secretTTL {
+		return cached.data, nil
+	}
+
+	secret, err := s.client.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, c := range s.secrets {
+		if now.Sub(c.read) >= secretTTL {
+			delete(s.secrets, k)
+		}
+	}
+	s.secrets[key] = cachedSecret{data: secret.Data, read: now}
+	return secret.Data, nil
+}
+
+func wantsEvent(n v1alpha1.Notification, event v1alpha1.NotificationEvent) bool {
+	if len(n.Events) == 0 {
+		return true
+	}
+	for _, e := range n.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
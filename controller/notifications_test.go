@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func TestSecretCache(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "scaler-webhook"},
+		Data: map[string][]byte{"hmac-key": []byte("first")}}
+	client := fake.NewSimpleClientset(secret)
+	secrets := newSecretCache(client)
+	now := time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC)
+	secrets.now = func() time.Time { return now }
+
+	data, err := secrets.get("default", "scaler-webhook")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data["hmac-key"]))
+
+	rotated := secret.DeepCopy()
+	rotated.Data["hmac-key"] = []byte("second")
+	_, err = client.CoreV1().Secrets("default").Update(rotated)
+	assert.NoError(t, err)
+	reads := len(client.Actions())
+	now = now.Add(30 * time.Second)
+	data, err = secrets.get("default", "scaler-webhook")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data["hmac-key"]), "the secret is reused for a while")
+	assert.Len(t, client.Actions(), reads)
+
+	now = now.Add(secretTTL)
+	data, err = secrets.get("default", "scaler-webhook")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(data["hmac-key"]), "a rotated key is picked up")
+
+	_, err = secrets.get("default", "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OverrideExpired    = "OverrideExpired"
+	ErrInvalidOverride = "ErrInvalidOverride"
+	TargetPinned       = "TargetPinned"
+)
+
+// overrideAnnotations maps the annotations which take a replica count to the type of override
+var overrideAnnotations = map[string]v1alpha1.ScalerOverrideType{
+	v1alpha1.PinReplicasAnnotation: v1alpha1.OverridePinReplicas,
+	v1alpha1.MinOverrideAnnotation: v1alpha1.OverrideMinReplicas,
+	v1alpha1.MaxOverrideAnnotation: v1alpha1.OverrideMaxReplicas,
+}
+
+// overrides are the annotations on a Scaler which are currently in effect
+type overrides struct {
+	paused bool
+	pin    *int32
+	min    *int32
+	max    *int32
+	// active is what is recorded in the status
+	active []v1alpha1.ScalerOverride
+	// expired are the annotations whose time has passed and which should be removed
+	expired []string
+	// invalid are the annotations which could not be parsed
+	invalid map[string]error
+	// schedule is the active schedule. The annotations take precedence over it.
+	schedule *v1alpha1.Schedule
+}
+
+// minReplicas returns the minimum replicas taking the override and the schedule into account
+func (o overrides) minReplicas(scaler *v1alpha1.Scaler) int32 {
+	if o.min != nil {
+		return *o.min
+	}
+	if o.schedule != nil && o.schedule.MinReplicas != nil {
+		return *o.schedule.MinReplicas
+	}
+	return scaler.Spec.MinReplicas
+}
+
+// maxReplicas returns the maximum replicas taking the override and the schedule into account
+func (o overrides) maxReplicas(scaler *v1alpha1.Scaler) int32 {
+	if o.max != nil {
+		return *o.max
+	}
+	if o.schedule != nil && o.schedule.MaxReplicas != nil {
+		return *o.schedule.MaxReplicas
+	}
+	return scaler.Spec.MaxReplicas
+}
+
+// scaleUpThreshold returns the scale up threshold taking the schedule into account
+func (o overrides) scaleUpThreshold(scaler *v1alpha1.Scaler) int32 {
+	if o.schedule != nil && o.schedule.ScaleUp != nil {
+		return *o.schedule.ScaleUp
+	}
+	return scaler.Spec.ScaleUp
+}
+
+// scaleDownThreshold returns the scale down threshold taking the schedule into account
+func (o overrides) scaleDownThreshold(scaler *v1alpha1.Scaler) int32 {
+	if o.schedule != nil && o.schedule.ScaleDown != nil {
+		return *o.schedule.ScaleDown
+	}
+	return scaler.Spec.ScaleDown
+}
+
+// isDisabled returns true if the v1alpha1.DisabledAnnotation is set to true on the Scaler
+func isDisabled(scaler *v1alpha1.Scaler) (bool, error) {
+	value, ok := scaler.Annotations[v1alpha1.DisabledAnnotation]
+	if !ok {
+		return false, nil
+	}
+	return strconv.ParseBool(strings.TrimSpace(value))
+}
+
+// parseOverrides reads the override annotations at the given time
+func parseOverrides(annotations map[string]string, now time.Time) overrides {
+	result := overrides{invalid: map[string]error{}}
+
+	if value, ok := annotations[v1alpha1.PausedAnnotation]; ok {
+		paused, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			result.invalid[v1alpha1.PausedAnnotation] = err
+		} else if paused {
+			result.paused = true
+			result.active = append(result.active, v1alpha1.ScalerOverride{Type: v1alpha1.OverridePaused})
+		}
+	}
+
+	keys := make([]string, 0, len(overrideAnnotations))
+	for k := range overrideAnnotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, annotation := range keys {
+		value, ok := annotations[annotation]
+		if !ok {
+			continue
+		}
+		replicas, until, err := parseReplicaOverride(value)
+		if err != nil {
+			result.invalid[annotation] = err
+			continue
+		}
+		if until != nil && !until.After(now) {
+			result.expired = append(result.expired, annotation)
+			continue
+		}
+
+		override := v1alpha1.ScalerOverride{Type: overrideAnnotations[annotation], Replicas: &replicas}
+		if until != nil {
+			t := metav1.NewTime(*until)
+			override.Until = &t
+		}
+		result.active = append(result.active, override)
+
+		switch annotation {
+		case v1alpha1.PinReplicasAnnotation:
+			result.pin = &replicas
+		case v1alpha1.MinOverrideAnnotation:
+			result.min = &replicas
+		case v1alpha1.MaxOverrideAnnotation:
+			result.max = &replicas
+		}
+	}
+	return result
+}
+
+// overridesEqual compares the overrides ignoring the sub-second precision which is lost when
+// the times are serialized
+func overridesEqual(a, b []v1alpha1.ScalerOverride) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type {
+			return false
+		}
+		if (a[i].Replicas == nil) != (b[i].Replicas == nil) || (a[i].Replicas != nil && *a[i].Replicas != *b[i].Replicas) {
+			return false
+		}
+		if (a[i].Until == nil) != (b[i].Until == nil) || (a[i].Until != nil && a[i].Until.Unix() != b[i].Until.Unix()) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseReplicaOverride parses values of the form "N" or "N until=<RFC3339>"
+func parseReplicaOverride(value string) (int32, *time.Time, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, nil, fmt.Errorf("expected \"<replicas> [until=<RFC3339>]\" but got %q", value)
+	}
+	replicas, err := strconv.ParseInt(fields[0], 10, 32)
+	if err != nil || replicas < 0 {
+		return 0, nil, fmt.Errorf("invalid replica count %q", fields[0])
+	}
+	if len(fields) == 1 {
+		return int32(replicas), nil, nil
+	}
+	if !strings.HasPrefix(fields[1], "until=") {
+		return 0, nil, fmt.Errorf("expected until=<RFC3339> but got %q", fields[1])
+	}
+	until, err := time.Parse(time.RFC3339, strings.TrimPrefix(fields[1], "until="))
+	if err != nil {
+		return 0, nil, err
+	}
+	return int32(replicas), &until, nil
+}
+
+// syncOverrides removes the expired override annotations from the Scaler and records the
+// active ones in its status
+func (c *Controller) syncOverrides(scaler *v1alpha1.Scaler) (overrides, *v1alpha1.Scaler, error) {
+	var err error
+	o := parseOverrides(scaler.Annotations, time.Now())
+
+	if c.reported.changed(scalerKey(scaler), ErrInvalidOverride, errorsValue(o.invalid)) {
+		for annotation, parseErr := range o.invalid {
+			c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrInvalidOverride, "ignoring annotation %s: %v",
+				annotation, parseErr)
+		}
+	}
+
+	if len(o.expired) > 0 {
+		log.Infof("removing expired overrides %v from %s/%s", o.expired, scaler.Namespace, scaler.Name)
+		scaler, err = c.updateScaler(scaler, func(s *v1alpha1.Scaler) {
+			for _, annotation := range o.expired {
+				delete(s.Annotations, annotation)
+			}
+		})
+		if err != nil {
+			return o, scaler, err
+		}
+		for _, annotation := range o.expired {
+			c.recorder.Eventf(scaler, corev1.EventTypeNormal, OverrideExpired, "override %s has expired", annotation)
+		}
+	}
+
+	if overridesEqual(o.active, scaler.Status.Overrides) {
+		return o, scaler, nil
+	}
+	scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		status.Overrides = o.active
+	})
+	return o, scaler, err
+}
+
+// applyPin sets the target to the pinned replicas. The cooldown and the bounds do not apply,
+// the freezes do.
+func (c *Controller) applyPin(scaler *v1alpha1.Scaler, scale *autoscalingv1.Scale, targetGR schema.GroupResource,
+	replicas int32, freezes []freeze) error {
+	if scale.Spec.Replicas == replicas {
+		log.Infof("target of %s/%s is pinned at %d replicas", scaler.Namespace, scaler.Name, replicas)
+		return nil
+	}
+	d := decision{currentReplicas: scale.Spec.Replicas, desiredReplicas: replicas}
+	if d = c.suppressFrozen(scaler, d, freezes); d.blocked {
+		return nil
+	}
+
+	if c.dryRun(scaler) {
+		c.recorder.Eventf(scaler, corev1.EventTypeNormal, ScalingRecommended, "would pin target %s/%s at %d replicas",
+			scale.Namespace, scale.Name, replicas)
+		return nil
+	}
+
+	from := scale.Spec.Replicas
+	scaler, err := c.updateTargetReplicas(scaler, scale, targetGR, replicas)
+	if err != nil {
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrUpdateTarget, "failed to pin target %s/%s: %v",
+			scale.Namespace, scale.Name, err)
+		return err
+	}
+	c.recorder.Eventf(scaler, corev1.EventTypeNormal, TargetPinned, "pinned target %s/%s at %d replicas",
+		scale.Namespace, scale.Name, replicas)
+	c.audit(scaler, replicaChange(from, replicas, "pinned by the "+v1alpha1.PinReplicasAnnotation+" annotation"), nil)
+
+	_, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		status.Condition = fmt.Sprintf("Pinned to %d replicas", replicas)
+		status.CurrentReplicas = replicas
+	})
+	return err
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseOverrides(t *testing.T) {
+	now := time.Date(2019, 1, 2, 12, 0, 0, 0, time.UTC)
+	int32Ptr := func(i int32) *int32 { return &i }
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		paused      bool
+		pin         *int32
+		min         *int32
+		max         *int32
+		expired     []string
+		invalid     []string
+	}{
+		{
+			name:        "no annotations",
+			annotations: map[string]string{},
+		},
+		{
+			name:        "paused",
+			annotations: map[string]string{v1alpha1.PausedAnnotation: "true"},
+			paused:      true,
+		},
+		{
+			name:        "not paused",
+			annotations: map[string]string{v1alpha1.PausedAnnotation: "false"},
+		},
+		{
+			name:        "pin without expiry",
+			annotations: map[string]string{v1alpha1.PinReplicasAnnotation: "5"},
+			pin:         int32Ptr(5),
+		},
+		{
+			name: "active and expired overrides",
+			annotations: map[string]string{
+				v1alpha1.MinOverrideAnnotation: "4 until=2019-01-02T13:00:00Z",
+				v1alpha1.MaxOverrideAnnotation: "20 until=2019-01-02T11:00:00Z",
+			},
+			min:     int32Ptr(4),
+			expired: []string{v1alpha1.MaxOverrideAnnotation},
+		},
+		{
+			name: "invalid overrides",
+			annotations: map[string]string{
+				v1alpha1.PausedAnnotation:      "yes please",
+				v1alpha1.PinReplicasAnnotation: "five",
+				v1alpha1.MaxOverrideAnnotation: "20 until=tomorrow",
+			},
+			invalid: []string{v1alpha1.PausedAnnotation, v1alpha1.PinReplicasAnnotation, v1alpha1.MaxOverrideAnnotation},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			o := parseOverrides(c.annotations, now)
+			assert.Equal(t, c.paused, o.paused)
+			assert.Equal(t, c.pin, o.pin)
+			assert.Equal(t, c.min, o.min)
+			assert.Equal(t, c.max, o.max)
+			assert.Equal(t, c.expired, o.expired)
+			assert.Len(t, o.invalid, len(c.invalid))
+			for _, annotation := range c.invalid {
+				assert.Contains(t, o.invalid, annotation)
+			}
+		})
+	}
+}
+
+func TestOverrideBounds(t *testing.T) {
+	scaler := &v1alpha1.Scaler{Spec: v1alpha1.ScalerSpec{MinReplicas: 2, MaxReplicas: 10}}
+	o := parseOverrides(map[string]string{v1alpha1.MaxOverrideAnnotation: "30"}, time.Now())
+	assert.Equal(t, int32(2), o.minReplicas(scaler))
+	assert.Equal(t, int32(30), o.maxReplicas(scaler))
+}
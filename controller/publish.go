@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EventScaled is published after the target was scaled
+	EventScaled = "scaler.scaled"
+	// EventBlocked is published when a decision was blocked by the bounds, the cooldown or a freeze
+	EventBlocked = "scaler.blocked"
+	// EventMetricsFailed is published when the replicas could not be computed from the metrics
+	EventMetricsFailed = "scaler.metrics-failed"
+)
+
+// Publisher emits the decisions of the controller to an event bus. Publish must not block.
+type Publisher interface {
+	Publish(eventType, subject string, data interface{}) bool
+}
+
+// publish emits the decision with the Scaler as the subject
+func (c *Controller) publish(eventType string, scaler *v1alpha1.Scaler, entry v1alpha1.ScalingDecision) {
+	if c.options.Publisher == nil {
+		return
+	}
+	c.options.Publisher.Publish(eventType, scalerKey(scaler), entry)
+}
+
+// metricsFailure is the decision record of an evaluation which failed to get the metrics
+func metricsFailure(replicas int32, err error) v1alpha1.ScalingDecision {
+	return v1alpha1.ScalingDecision{
+		Time:         metav1.Now(),
+		FromReplicas: replicas,
+		ToReplicas:   replicas,
+		Reason:       err.Error(),
+	}
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// reported remembers what was last reported about a Scaler on every topic so that a warning or
+// event which would be the same on every resync is only emitted when it changes
+type reported struct {
+	mu   sync.Mutex
+	last map[string]map[string]string
+}
+
+func newReported() *reported {
+	return &reported{last: map[string]map[string]string{}}
+}
+
+// changed records the value of the topic and returns true if it differs from the last value
+// recorded. An empty value means there is nothing to report and is not returned as a change
+// when nothing was recorded before.
+func (r *reported) changed(key, topic, value string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last[key][topic] == value {
+		return false
+	}
+	if value == "" {
+		delete(r.last[key], topic)
+		return true
+	}
+	if r.last[key] == nil {
+		r.last[key] = map[string]string{}
+	}
+	r.last[key][topic] = value
+	return true
+}
+
+func (r *reported) forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.last, key)
+}
+
+// errorsValue returns the errors as a value which does not depend on the order of the map
+func errorsValue(errs map[string]error) string {
+	values := make([]string, 0, len(errs))
+	for name, err := range errs {
+		values = append(values, name+": "+err.Error())
+	}
+	sort.Strings(values)
+	return strings.Join(values, "\n")
+}
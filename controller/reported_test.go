@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReported(t *testing.T) {
+	r := newReported()
+	assert.False(t, r.changed("default/web", "overrides", ""))
+	assert.True(t, r.changed("default/web", "overrides", "invalid"))
+	assert.False(t, r.changed("default/web", "overrides", "invalid"))
+	assert.True(t, r.changed("default/web", "schedules", "invalid"))
+	assert.True(t, r.changed("default/web", "overrides", ""))
+	assert.True(t, r.changed("default/web", "overrides", "invalid"))
+
+	r.forget("default/web")
+	assert.True(t, r.changed("default/web", "overrides", "invalid"))
+}
+
+func TestErrorsValue(t *testing.T) {
+	errs := map[string]error{"b": fmt.Errorf("second"), "a": fmt.Errorf("first")}
+	assert.Equal(t, "a: first\nb: second", errorsValue(errs))
+	assert.Equal(t, "", errorsValue(nil))
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	RolloutProgressing = "RolloutProgressing"
+	RolloutComplete    = "RolloutComplete"
+)
+
+// workloadGroups are the API groups the watched workloads can be referenced through by a Scaler
+var workloadGroups = []string{"apps", "extensions"}
+
+// enqueueScalersForWorkload returns an event handler which queues the Scalers targeting a workload of the kind
+func (c *Controller) enqueueScalersForWorkload(kind string) func(obj interface{}) {
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return
+		}
+		for _, group := range workloadGroups {
+			c.enqueueScalersForTarget(groupTargetKey(m.GetNamespace(), group, kind, m.GetName()))
+		}
+	}
+}
+
+// rolloutStatus returns true and a description if the target of the Scaler is in the middle of
+// a rollout. While old and new pods run side by side their CPU usage is not a good signal for
+// scaling. Targets which are not Deployments or StatefulSets are never considered to be rolling out.
+func (c *Controller) rolloutStatus(scaler *v1alpha1.Scaler) (bool, string, error) {
+	target := scaler.Spec.Target
+	gv, err := schema.ParseGroupVersion(target.APIVersion)
+	if err != nil || (gv.Group != "apps" && gv.Group != "extensions") {
+		return false, "", nil
+	}
+
+	switch target.Kind {
+	case "Deployment":
+		deployment, err := c.deploymentsLister.Deployments(scaler.Namespace).Get(target.Name)
+		if errors.IsNotFound(err) {
+			return false, "", nil
+		}
+		if err != nil {
+			return false, "", err
+		}
+		return deploymentRolloutInProgress(deployment)
+	case "StatefulSet":
+		statefulSet, err := c.statefulSetsLister.StatefulSets(scaler.Namespace).Get(target.Name)
+		if errors.IsNotFound(err) {
+			return false, "", nil
+		}
+		if err != nil {
+			return false, "", err
+		}
+		return statefulSetRolloutInProgress(statefulSet)
+	}
+	return false, "", nil
+}
+
+// deploymentRolloutInProgress only looks at the generation and the updated replicas. A plain
+// scale up or down has no old replicas, so it is not a rollout even while the new pods are not
+// created or available yet.
+func deploymentRolloutInProgress(d *appsv1.Deployment) (bool, string, error) {
+	if d.Generation > d.Status.ObservedGeneration {
+		return true, "waiting for the deployment spec update to be observed", nil
+	}
+	if d.Status.Replicas > d.Status.UpdatedReplicas {
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		return true, fmt.Sprintf("%d out of %d new replicas have been updated, %d old replicas are left",
+			d.Status.UpdatedReplicas, replicas, d.Status.Replicas-d.Status.UpdatedReplicas), nil
+	}
+	return false, "", nil
+}
+
+func statefulSetRolloutInProgress(s *appsv1.StatefulSet) (bool, string, error) {
+	if s.Generation > s.Status.ObservedGeneration {
+		return true, "waiting for the statefulset spec update to be observed", nil
+	}
+	if s.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		// with the OnDelete strategy pods are only replaced by hand so there is no rollout to wait for
+		return false, "", nil
+	}
+	if s.Status.UpdateRevision != "" && s.Status.CurrentRevision != s.Status.UpdateRevision {
+		return true, fmt.Sprintf("%d out of %d pods have been updated to revision %s", s.Status.UpdatedReplicas,
+			s.Status.Replicas, s.Status.UpdateRevision), nil
+	}
+	return false, "", nil
+}
+
+// syncRollout sets the RolloutInProgress condition and returns true while the target is rolling out
+func (c *Controller) syncRollout(scaler *v1alpha1.Scaler) (bool, *v1alpha1.Scaler, error) {
+	inProgress, message, err := c.rolloutStatus(scaler)
+	if err != nil {
+		return false, scaler, err
+	}
+
+	if inProgress {
+		log.Infof("target of %s/%s is rolling out: %s", scaler.Namespace, scaler.Name, message)
+		if !conditionChanged(&scaler.Status, v1alpha1.ScalerRolloutInProgress, corev1.ConditionTrue, RolloutProgressing, message) {
+			return true, scaler, nil
+		}
+		scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+			setCondition(status, v1alpha1.ScalerRolloutInProgress, corev1.ConditionTrue, RolloutProgressing, message)
+		})
+		return true, scaler, err
+	}
+
+	if !isConditionTrue(&scaler.Status, v1alpha1.ScalerRolloutInProgress) {
+		return false, scaler, nil
+	}
+	log.Infof("rollout of the target of %s/%s has completed", scaler.Namespace, scaler.Name)
+	scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		setCondition(status, v1alpha1.ScalerRolloutInProgress, corev1.ConditionFalse, RolloutComplete, "the rollout has completed")
+	})
+	return false, scaler, err
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestDeploymentRolloutInProgress(t *testing.T) {
+	newDeployment := func(generation int64, status appsv1.DeploymentStatus) *appsv1.Deployment {
+		replicas := int32(4)
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     status,
+		}
+	}
+	testCases := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		expected   bool
+	}{
+		{name: "stable", deployment: newDeployment(2, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4,
+			UpdatedReplicas: 4, AvailableReplicas: 4}), expected: false},
+		{name: "scaling up with pods becoming available", deployment: newDeployment(3, appsv1.DeploymentStatus{
+			ObservedGeneration: 3, Replicas: 4, UpdatedReplicas: 4, AvailableReplicas: 2}), expected: false},
+		{name: "scaling up before the pods are created", deployment: newDeployment(3, appsv1.DeploymentStatus{
+			ObservedGeneration: 3, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}), expected: false},
+		{name: "spec change not observed", deployment: newDeployment(3, appsv1.DeploymentStatus{ObservedGeneration: 2,
+			Replicas: 4, UpdatedReplicas: 4, AvailableReplicas: 4}), expected: true},
+		{name: "old replicas left", deployment: newDeployment(3, appsv1.DeploymentStatus{ObservedGeneration: 3,
+			Replicas: 5, UpdatedReplicas: 2, AvailableReplicas: 4}), expected: true},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			inProgress, _, err := deploymentRolloutInProgress(c.deployment)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, inProgress)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/schedule"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"time"
+)
+
+const (
+	ScheduleActivated  = "ScheduleActivated"
+	ScheduleEnded      = "ScheduleEnded"
+	ErrInvalidSchedule = "ErrInvalidSchedule"
+
+	// scheduleBoundary is the topic of the next boundary the Scaler was queued for
+	scheduleBoundary = "schedule-boundary"
+)
+
+// scheduleWindow parses the cron expression, the time zone and the duration of the schedule
+func scheduleWindow(s v1alpha1.Schedule) (schedule.Window, error) {
+	cron, err := schedule.ParseCron(s.Cron)
+	if err != nil {
+		return schedule.Window{}, err
+	}
+	location := time.UTC
+	if s.TimeZone != "" {
+		if location, err = time.LoadLocation(s.TimeZone); err != nil {
+			return schedule.Window{}, err
+		}
+	}
+	if s.DurationSeconds <= 0 {
+		return schedule.Window{}, fmt.Errorf("durationSeconds must be positive")
+	}
+	return schedule.Window{Cron: cron, Duration: time.Duration(s.DurationSeconds) * time.Second, Location: location}, nil
+}
+
+// activeSchedule returns the first schedule which is active at the given time and the next
+// time at which any of the schedules starts or ends. Schedules which cannot be parsed are
+// returned by name with the error.
+func activeSchedule(schedules []v1alpha1.Schedule, now time.Time) (*v1alpha1.Schedule, time.Time, map[string]error) {
+	var (
+		active   *v1alpha1.Schedule
+		boundary time.Time
+		invalid  = map[string]error{}
+	)
+	for i := range schedules {
+		window, err := scheduleWindow(schedules[i])
+		if err != nil {
+			invalid[schedules[i].Name] = err
+			continue
+		}
+		if isActive, _ := window.Active(now); isActive && active == nil {
+			active = &schedules[i]
+		}
+		if next := window.NextBoundary(now); !next.IsZero() && (boundary.IsZero() || next.Before(boundary)) {
+			boundary = next
+		}
+	}
+	return active, boundary, invalid
+}
+
+// syncSchedules returns the active schedule of the Scaler and records it in the status. The
+// Scaler is queued again for the next time a schedule starts or ends.
+func (c *Controller) syncSchedules(scaler *v1alpha1.Scaler) (*v1alpha1.Schedule, *v1alpha1.Scaler, error) {
+	if len(scaler.Spec.Schedules) == 0 && scaler.Status.ActiveSchedule == "" {
+		return nil, scaler, nil
+	}
+
+	key := scalerKey(scaler)
+	active, boundary, invalid := activeSchedule(scaler.Spec.Schedules, time.Now())
+	if c.reported.changed(key, ErrInvalidSchedule, errorsValue(invalid)) {
+		for name, err := range invalid {
+			c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrInvalidSchedule, "ignoring schedule %s: %v", name, err)
+		}
+	}
+	// the Scaler stays queued for the boundary until it moves
+	if !boundary.IsZero() && c.reported.changed(key, scheduleBoundary, boundary.Format(time.RFC3339)) {
+		c.queue.AddAfter(key, time.Until(boundary))
+	}
+
+	name := ""
+	if active != nil {
+		name = active.Name
+	}
+	if name == scaler.Status.ActiveSchedule {
+		return active, scaler, nil
+	}
+
+	if active != nil {
+		log.Infof("schedule %s of %s/%s is active", name, scaler.Namespace, scaler.Name)
+		c.recorder.Eventf(scaler, corev1.EventTypeNormal, ScheduleActivated, "schedule %s is active", name)
+	} else {
+		log.Infof("schedule %s of %s/%s has ended", scaler.Status.ActiveSchedule, scaler.Namespace, scaler.Name)
+		c.recorder.Eventf(scaler, corev1.EventTypeNormal, ScheduleEnded, "schedule %s has ended",
+			scaler.Status.ActiveSchedule)
+	}
+	scaler, err := c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+		status.ActiveSchedule = name
+	})
+	return active, scaler, err
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"testing"
+	"time"
+)
+
+func TestActiveSchedule(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	schedules := []v1alpha1.Schedule{
+		{Name: "broken", Cron: "0 25 * * *", DurationSeconds: 3600},
+		{Name: "day", Cron: "0 8 * * 1-5", DurationSeconds: 14 * 3600, MinReplicas: int32Ptr(20)},
+		{Name: "night", Cron: "0 22 * * *", DurationSeconds: 10 * 3600, MinReplicas: int32Ptr(2)},
+		{Name: "always", Cron: "* * * * *", DurationSeconds: 60, MaxReplicas: int32Ptr(50)},
+	}
+
+	testCases := []struct {
+		name     string
+		now      time.Time
+		active   string
+		boundary time.Time
+	}{
+		{
+			name:     "weekday morning",
+			now:      time.Date(2019, 1, 4, 9, 0, 30, 0, time.UTC),
+			active:   "day",
+			boundary: time.Date(2019, 1, 4, 9, 1, 0, 0, time.UTC),
+		},
+		{
+			name:     "night",
+			now:      time.Date(2019, 1, 5, 3, 0, 30, 0, time.UTC),
+			active:   "night",
+			boundary: time.Date(2019, 1, 5, 3, 1, 0, 0, time.UTC),
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			active, boundary, invalid := activeSchedule(schedules, c.now)
+			assert.NotNil(t, active)
+			if active != nil {
+				assert.Equal(t, c.active, active.Name)
+			}
+			assert.True(t, c.boundary.Equal(boundary), "expected boundary %s but got %s", c.boundary, boundary)
+			assert.Len(t, invalid, 1)
+			assert.NotNil(t, invalid["broken"])
+		})
+	}
+
+	o := overrides{schedule: &schedules[1]}
+	scaler := &v1alpha1.Scaler{Spec: v1alpha1.ScalerSpec{MinReplicas: 1, MaxReplicas: 30, ScaleUp: 70}}
+	assert.Equal(t, int32(20), o.minReplicas(scaler))
+	assert.Equal(t, int32(30), o.maxReplicas(scaler))
+	assert.Equal(t, int32(70), o.scaleUpThreshold(scaler))
+	o.min = int32Ptr(5)
+	assert.Equal(t, int32(5), o.minReplicas(scaler), "the annotation takes precedence over the schedule")
+}
+
+// countingQueue counts the keys added with a delay
+type countingQueue struct {
+	workqueue.RateLimitingInterface
+	delayed int
+}
+
+func (q *countingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.delayed++
+}
+
+func TestSyncSchedulesReportsChanges(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	queue := &countingQueue{}
+	c := &Controller{recorder: recorder, queue: queue, reported: newReported()}
+	scaler := &v1alpha1.Scaler{
+		Spec: v1alpha1.ScalerSpec{Schedules: []v1alpha1.Schedule{
+			{Name: "broken", Cron: "0 25 * * *", DurationSeconds: 3600},
+			{Name: "daily", Cron: "0 8 * * *", DurationSeconds: 3600},
+		}},
+	}
+	scaler.Namespace, scaler.Name = "default", "web"
+
+	for i := 0; i < 3; i++ {
+		_, _, err := c.syncSchedules(scaler)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, recorder.Events, 1, "the invalid schedule is only reported once")
+	assert.Equal(t, 1, queue.delayed, "the Scaler is only queued again when the boundary moves")
+
+	scaler.Spec.Schedules[0].Cron = "0 26 * * *"
+	_, _, err := c.syncSchedules(scaler)
+	assert.NoError(t, err)
+	assert.Len(t, recorder.Events, 2, "a changed error is reported again")
+}
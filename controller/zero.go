@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+const (
+	TargetIdle         = "TargetIdle"
+	TargetInUse        = "TargetInUse"
+	ErrActivationQuery = "ErrActivationQuery"
+)
+
+// activation is the state of a Scaler which can scale to zero
+type activation struct {
+	// enabled is true when the Scaler has an activation query
+	enabled bool
+	// value is the result of the activation query
+	value float64
+	// inUse is true when the value is above the activation threshold
+	inUse bool
+	// idle is true when the target has not been in use for the idle period
+	idle bool
+}
+
+// activationReplicas returns the replicas a target is scaled to from zero
+func activationReplicas(s *v1alpha1.ScaleToZero) int32 {
+	if s.ActivationReplicas < 1 {
+		return 1
+	}
+	return s.ActivationReplicas
+}
+
+// activationThreshold returns the activation threshold of the Scaler as a float
+func activationThreshold(s *v1alpha1.ScaleToZero) float64 {
+	return float64(s.ActivationThreshold.MilliValue()) / 1000
+}
+
+// idleRemaining returns how much longer a target which has not been in use since the given
+// time has to stay unused before it is idle. It is zero or negative once the target is idle.
+func idleRemaining(since time.Time, idleSeconds int32, now time.Time) time.Duration {
+	return since.Add(time.Duration(idleSeconds) * time.Second).Sub(now)
+}
+
+// syncActivation evaluates the activation query of a Scaler which can scale to zero and keeps
+// track of how long the target has not been in use in its status. The Scaler is queued again
+// for the time the idle period ends.
+func (c *Controller) syncActivation(scaler *v1alpha1.Scaler) (activation, *v1alpha1.Scaler, error) {
+	var err error
+	stz := scaler.Spec.ScaleToZero
+	if stz == nil {
+		if scaler.Status.IdleSince != nil {
+			scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+				status.IdleSince = nil
+			})
+		}
+		return activation{}, scaler, err
+	}
+
+	source, err := c.activationSource(scaler)
+	if err != nil {
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrActivationQuery, "failed to run the activation query: %v", err)
+		return activation{}, scaler, err
+	}
+	if source == nil {
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrActivationQuery,
+			"the metrics source does not support activation queries")
+		return activation{}, scaler, fmt.Errorf("the metrics source does not support activation queries")
+	}
+	value, err := source.GetActivationValue(stz.ActivationQuery)
+	if err != nil {
+		c.recorder.Eventf(scaler, corev1.EventTypeWarning, ErrActivationQuery, "failed to run the activation query: %v", err)
+		return activation{}, scaler, err
+	}
+
+	a := activation{enabled: true, value: value, inUse: value > activationThreshold(stz)}
+	if a.inUse {
+		if scaler.Status.IdleSince == nil {
+			return a, scaler, nil
+		}
+		log.Infof("target of %s/%s is in use again", scaler.Namespace, scaler.Name)
+		c.recorder.Eventf(scaler, corev1.EventTypeNormal, TargetInUse, "activation value %g is above the threshold of %s",
+			value, stz.ActivationThreshold.String())
+		scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+			status.IdleSince = nil
+		})
+		return a, scaler, err
+	}
+
+	if scaler.Status.IdleSince == nil {
+		now := metav1.Now()
+		log.Infof("target of %s/%s is not in use", scaler.Namespace, scaler.Name)
+		c.recorder.Eventf(scaler, corev1.EventTypeNormal, TargetIdle,
+			"activation value %g is not above the threshold of %s. idle after %d seconds", value,
+			stz.ActivationThreshold.String(), stz.IdleSeconds)
+		scaler, err = c.updateStatus(scaler, func(status *v1alpha1.ScalerStatus) {
+			status.IdleSince = &now
+		})
+		if err != nil {
+			return a, scaler, err
+		}
+	}
+	remaining := idleRemaining(scaler.Status.IdleSince.Time, stz.IdleSeconds, time.Now())
+	a.idle = remaining <= 0
+	if !a.idle {
+		c.queue.AddAfter(scalerKey(scaler), remaining)
+	}
+	return a, scaler, nil
+}
+
+// currentActivation evaluates the activation query of the Scaler like syncActivation but does
+// not record anything. A target which has not been seen idle by the controller is not idle yet.
+func (c *Controller) currentActivation(scaler *v1alpha1.Scaler, now time.Time) (activation, error) {
+	stz := scaler.Spec.ScaleToZero
+	if stz == nil {
+		return activation{}, nil
+	}
+	source, err := c.activationSource(scaler)
+	if err != nil {
+		return activation{}, err
+	}
+	if source == nil {
+		return activation{}, fmt.Errorf("the metrics source does not support activation queries")
+	}
+	value, err := source.GetActivationValue(stz.ActivationQuery)
+	if err != nil {
+		return activation{}, err
+	}
+	a := activation{enabled: true, value: value, inUse: value > activationThreshold(stz)}
+	a.idle = !a.inUse && scaler.Status.IdleSince != nil &&
+		idleRemaining(scaler.Status.IdleSince.Time, stz.IdleSeconds, now) <= 0
+	return a, nil
+}
+
+// decideFromZero decides whether a target without any replicas has to be activated
+func decideFromZero(d decision, scaler *v1alpha1.Scaler, a activation) decision {
+	stz := scaler.Spec.ScaleToZero
+	if !a.inUse && d.minReplicas == 0 {
+		d.reason = fmt.Sprintf("activation value %g is not above the threshold of %s", a.value,
+			stz.ActivationThreshold.String())
+		return d
+	}
+
+	replicas := d.minReplicas
+	d.reason = fmt.Sprintf("the minimum replicas are %d", d.minReplicas)
+	if a.inUse && activationReplicas(stz) > replicas {
+		replicas = activationReplicas(stz)
+		d.reason = fmt.Sprintf("activation value %g above the threshold of %s", a.value, stz.ActivationThreshold.String())
+	}
+	if replicas > d.maxReplicas {
+		replicas = d.maxReplicas
+	}
+	d.desiredReplicas = replicas
+	return d
+}
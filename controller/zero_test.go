@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"testing"
+	"time"
+)
+
+func TestDecideScaleToZero(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	scaler := &v1alpha1.Scaler{Spec: v1alpha1.ScalerSpec{
+		MinReplicas: 0,
+		MaxReplicas: 10,
+		ScaleToZero: &v1alpha1.ScaleToZero{
+			ActivationQuery:     `sum(rate(nginx_ingress_controller_requests{service="web"}[5m]))`,
+			ActivationThreshold: resource.MustParse("500m"),
+			ActivationReplicas:  3,
+			IdleSeconds:         600,
+		},
+	}}
+
+	testCases := []struct {
+		name     string
+		replicas int32
+		min      *int32
+		a        activation
+		desired  int32
+	}{
+		{name: "stays at zero without traffic", replicas: 0, a: activation{enabled: true, value: 0.2}, desired: 0},
+		{name: "activates with traffic", replicas: 0, a: activation{enabled: true, value: 4, inUse: true}, desired: 3},
+		{name: "activates to the minimum", replicas: 0, min: int32Ptr(5), a: activation{enabled: true, value: 4, inUse: true}, desired: 5},
+		{name: "activates when the minimum is raised", replicas: 0, min: int32Ptr(2), a: activation{enabled: true}, desired: 2},
+		{name: "scales idle target to zero", replicas: 4, a: activation{enabled: true, idle: true}, desired: 0},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			o := overrides{min: c.min}
+			scale := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: c.replicas}}
+			d, err := (&Controller{}).decide(scaler, scale, o, c.a)
+			assert.NoError(t, err)
+			assert.False(t, d.blocked)
+			assert.Equal(t, c.desired, d.desiredReplicas)
+		})
+	}
+}
+
+func TestZeroInCooldown(t *testing.T) {
+	scaler := &v1alpha1.Scaler{
+		Spec: v1alpha1.ScalerSpec{
+			MinReplicas: 0,
+			MaxReplicas: 10,
+			ScaleToZero: &v1alpha1.ScaleToZero{ActivationReplicas: 3, IdleSeconds: 600},
+		},
+		Status: v1alpha1.ScalerStatus{LastScalingTimestamp: time.Now().Add(-30 * time.Second).Format(time.RFC3339)},
+	}
+
+	testCases := []struct {
+		name     string
+		replicas int32
+		a        activation
+	}{
+		{name: "activation", replicas: 0, a: activation{enabled: true, value: 4, inUse: true}},
+		{name: "idle target", replicas: 4, a: activation{enabled: true, idle: true}},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			scale := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: c.replicas}}
+			d, err := (&Controller{}).decide(scaler, scale, overrides{}, c.a)
+			assert.NoError(t, err)
+			assert.True(t, d.blocked)
+			assert.True(t, d.cooldown)
+			assert.False(t, d.scale())
+		})
+	}
+}
+
+func TestIdleRemaining(t *testing.T) {
+	since := time.Date(2019, 1, 2, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 4*time.Minute, idleRemaining(since, 600, since.Add(6*time.Minute)))
+	assert.True(t, idleRemaining(since, 600, since.Add(10*time.Minute)) <= 0)
+}
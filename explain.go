@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/arjunrn/simple-scaler/controller"
+	clientset "github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	prometheus_api "github.com/prometheus/client_golang/api"
+	"io"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cacheddiscovery "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/scale"
+	"k8s.io/client-go/tools/clientcmd"
+	"strings"
+	"text/tabwriter"
+)
+
+// runExplain implements the explain subcommand. It evaluates a Scaler once and prints the
+// samples of every pod, the decision and the gate which would keep it from being applied.
+func runExplain(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	var (
+		kubeconfig string
+		master     string
+		promURL    string
+		source     string
+		namespace  string
+	)
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	flags.StringVar(&master, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig.")
+	flags.StringVar(&promURL, "prometheus-url", "", "Address of the prometheus server")
+	flags.StringVar(&source, "metrics-source", "prometheus", "Where the pod metrics come from. Either prometheus or file://<path>")
+	flags.StringVar(&namespace, "n", metav1.NamespaceDefault, "Namespace of the Scaler")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: simple-scaler explain [-n namespace] <scaler>")
+	}
+
+	cfg, err := clientcmd.BuildConfigFromFlags(master, kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to build the kubeconfig: %v", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	scalerClient, err := clientset.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(cacheddiscovery.NewMemCacheClient(kubeClient.Discovery()))
+	scaleGetter, err := scale.NewForConfig(cfg, mapper, dynamic.LegacyAPIPathResolverFunc,
+		scale.NewDiscoveryScaleKindResolver(kubeClient.Discovery()))
+	if err != nil {
+		return err
+	}
+
+	metrics, _, err := newMetricsSource(source)
+	if err != nil {
+		return fmt.Errorf("invalid metrics source %q: %v", source, err)
+	}
+	if metrics == nil {
+		prometheusClient, err := prometheus_api.NewClient(prometheus_api.Config{Address: promURL})
+		if err != nil {
+			return fmt.Errorf("failed to create the prometheus client: %v", err)
+		}
+		metrics = replicacalculator.NewPrometheusMetricsSource(prometheusClient)
+	}
+
+	explainer := controller.NewExplainer(kubeClient, scalerClient, scaleGetter, mapper, metrics)
+	explanation, err := explainer.Explain(namespace, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	printExplanation(out, explanation)
+	return nil
+}
+
+func printExplanation(out io.Writer, x *controller.Explanation) {
+	fmt.Fprintf(out, "Target:          %s\n", x.Target)
+	fmt.Fprintf(out, "Replicas:        %d (min %d, max %d)\n", x.CurrentReplicas, x.MinReplicas, x.MaxReplicas)
+	fmt.Fprintf(out, "Thresholds:      up %d%%, down %d%% for %d evaluations\n", x.ScaleUpThreshold,
+		x.ScaleDownThreshold, x.Evaluations)
+	if x.Selector != "" {
+		fmt.Fprintf(out, "Selector:        %s\n", x.Selector)
+	}
+	for _, note := range x.Notes {
+		fmt.Fprintf(out, "Note:            %s\n", note)
+	}
+
+	if len(x.Pods) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "POD\tSAMPLES\tVERDICT")
+		for _, p := range x.Pods {
+			samples := make([]string, len(p.Samples))
+			for i, s := range p.Samples {
+				samples[i] = fmt.Sprintf("%d", s)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, strings.Join(samples, " "), p.Verdict)
+		}
+		w.Flush()
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Utilization:     average %d%%, min %d%%, max %d%% over %d pods\n", x.Utilization.Average,
+			x.Utilization.Min, x.Utilization.Max, x.Utilization.Pods)
+	}
+
+	fmt.Fprintf(out, "Desired:         %d replicas\n", x.DesiredReplicas)
+	fmt.Fprintf(out, "Reason:          %s\n", x.Reason)
+	if x.Gate == "" {
+		fmt.Fprintf(out, "Result:          the controller would scale from %d to %d replicas\n", x.CurrentReplicas,
+			x.DesiredReplicas)
+		return
+	}
+	fmt.Fprintf(out, "Result:          blocked by %s\n", x.Gate)
+}
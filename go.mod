@@ -1,10 +1,27 @@
 module github.com/arjunrn/simple-scaler
 
+go 1.19
+
+require (
+	github.com/golang/glog v1.2.0
+	github.com/prometheus/client_golang v0.9.1
+	github.com/prometheus/common v0.0.0-20181116084131-1f2c4f3cd6db
+	github.com/sirupsen/logrus v1.2.0
+	github.com/spf13/pflag v1.0.1
+	github.com/stretchr/testify v1.2.2
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.33.0
+	k8s.io/api v0.0.0-20180925152912-a191abe0b71e
+	k8s.io/apimachinery v0.0.0-20180925152725-5ae511e0ed34
+	k8s.io/client-go v9.0.0+incompatible
+)
+
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/ghodss/yaml v0.0.0-20150909031657-73d445a93680 // indirect
 	github.com/gogo/protobuf v0.0.0-20170330071051-c0656edd0d9e // indirect
-	github.com/golang/glog v0.0.0-20141105023935-44145f04b68c
 	github.com/golang/groupcache v0.0.0-20160516000752-02826c3e7903 // indirect
+	github.com/golang/protobuf v1.5.4 // indirect
 	github.com/google/btree v0.0.0-20160524151835-7d79101e329e // indirect
 	github.com/google/gofuzz v0.0.0-20161122191042-44d81051d367 // indirect
 	github.com/googleapis/gnostic v0.0.0-20170729233727-0c5108395e2d // indirect
@@ -12,24 +29,26 @@ require (
 	github.com/hashicorp/golang-lru v0.0.0-20160207214719-a0d98a5f2880 // indirect
 	github.com/imdario/mergo v0.3.5 // indirect
 	github.com/json-iterator/go v0.0.0-20180612202835-f2b4162afba3 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.1 // indirect
+	github.com/onsi/ginkgo v1.6.0 // indirect
 	github.com/onsi/gomega v1.4.2 // indirect
 	github.com/peterbourgon/diskv v2.0.1+incompatible // indirect
-	github.com/prometheus/client_golang v0.9.1
-	github.com/prometheus/common v0.0.0-20181116084131-1f2c4f3cd6db
-	github.com/sirupsen/logrus v1.2.0
-	github.com/spf13/pflag v1.0.1 // indirect
-	github.com/stretchr/testify v1.2.2
-	golang.org/x/oauth2 v0.0.0-20170412232759-a6bd8cefa181 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.21.0 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/oauth2 v0.18.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/term v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 	golang.org/x/time v0.0.0-20161028155119-f51c12702a4d // indirect
-	google.golang.org/appengine v1.2.0 // indirect
+	google.golang.org/appengine v1.6.8 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 	gopkg.in/inf.v0 v0.9.0 // indirect
-	k8s.io/api v0.0.0-20180925152912-a191abe0b71e
-	k8s.io/apimachinery v0.0.0-20180925152725-5ae511e0ed34
-	k8s.io/client-go v9.0.0+incompatible //indirect
+	gopkg.in/yaml.v2 v2.2.1 // indirect
 	k8s.io/kube-openapi v0.0.0-20180711000925-0cf8f7e6ed1d // indirect
 )
 
@@ -3,12 +3,25 @@ package main
 import (
 	"flag"
 	"github.com/arjunrn/simple-scaler/controller"
+	"github.com/arjunrn/simple-scaler/pkg/api"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler"
+	"github.com/arjunrn/simple-scaler/pkg/audit"
 	clientset "github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned"
 	scalerinformers "github.com/arjunrn/simple-scaler/pkg/client/informers/externalversions"
+	informers "github.com/arjunrn/simple-scaler/pkg/client/informers/externalversions/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/cloudevents"
+	"github.com/arjunrn/simple-scaler/pkg/dashboard"
+	"github.com/arjunrn/simple-scaler/pkg/externalmetrics"
+	"github.com/arjunrn/simple-scaler/pkg/httpauth"
+	"github.com/arjunrn/simple-scaler/pkg/notify"
+	"github.com/arjunrn/simple-scaler/pkg/sharding"
 	"github.com/arjunrn/simple-scaler/pkg/signals"
 	"github.com/golang/glog"
 	prometheus_api "github.com/prometheus/client_golang/api"
 	log "github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	cacheddiscovery "k8s.io/client-go/discovery/cached"
 	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
@@ -16,18 +29,69 @@ import (
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/clientcmd"
+	"net/http"
+	"os"
 	"time"
 )
 
+// version is set at build time
+var version = "dev"
+
 var (
 	masterURL      string
 	kubeconfig     string
 	prometheusURL  string
 	resyncInterval int
 	debugLogging   bool
+	shardGroup     string
+	shardNamespace string
+	shardIdentity  string
+	shardLease     int
+	namespace      string
+	scalerSelector string
+	scalingFreezes bool
+	driftGrace     int
+	dryRun         bool
+	historyLength  int
+	auditFile      string
+	auditFileSize  int
+	auditBackups   int
+	auditRecords   bool
+	auditTTL       int
+	notifyQueue    int
+	notifyAttempts int
+	eventsURL      string
+	eventsMode     string
+	eventsSource   string
+	eventsBuffer   int
+	metricsSource  string
+	dashboardAddr  string
+	dashboardToken string
+	dashboardCert  string
+	dashboardKey   string
+	apiAddr        string
+	apiToken       string
+	apiCert        string
+	apiKey         string
+	metricsAddr    string
+	metricsCert    string
+	metricsKey     string
+	metricsCA      string
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		if err := runBacktest(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("backtest failed: %s", err.Error())
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		if err := runExplain(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("explain failed: %s", err.Error())
+		}
+		return
+	}
 	flag.Parse()
 
 	logger := log.New()
@@ -57,8 +121,23 @@ func main() {
 		log.Fatalf("error building scaler clientset: %s", err.Error())
 	}
 
-	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
-	scalerInformerFactory := scalerinformers.NewSharedInformerFactory(scalerClient, time.Second*30)
+	if _, err := labels.Parse(scalerSelector); err != nil {
+		log.Fatalf("invalid scaler selector %q: %s", scalerSelector, err.Error())
+	}
+
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, time.Second*30,
+		kubeinformers.WithNamespace(namespace))
+	scalerInformerFactory := scalerinformers.NewSharedInformerFactoryWithOptions(scalerClient, time.Second*30,
+		scalerinformers.WithNamespace(namespace),
+		scalerinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = scalerSelector
+		}))
+	// ScalingFreezes are cluster scoped and must not be filtered by the namespace or the scaler selector
+	freezeInformerFactory := scalerinformers.NewSharedInformerFactory(scalerClient, time.Second*30)
+	var freezeInformer informers.ScalingFreezeInformer
+	if scalingFreezes {
+		freezeInformer = freezeInformerFactory.Arjunnaik().V1alpha1().ScalingFreezes()
+	}
 
 	cachedClient := cacheddiscovery.NewMemCacheClient(kubeClient.Discovery())
 	// TODO: understand what this caching is all about and why its needed
@@ -74,6 +153,9 @@ func main() {
 	}
 
 	podInformer := kubeInformerFactory.Core().V1().Pods()
+	hpaInformer := kubeInformerFactory.Autoscaling().V1().HorizontalPodAutoscalers()
+	deploymentInformer := kubeInformerFactory.Apps().V1().Deployments()
+	statefulSetInformer := kubeInformerFactory.Apps().V1().StatefulSets()
 
 	config := prometheus_api.Config{Address: prometheusURL}
 	prometheusClient, err := prometheus_api.NewClient(config)
@@ -83,15 +165,140 @@ func main() {
 
 	interval := time.Duration(resyncInterval) * time.Second
 
+	var shards *sharding.Coordinator
+	if shardGroup != "" {
+		// every replica only has the evaluations of the Scalers it owns, but the APIService
+		// balances the requests across all of them
+		if metricsAddr != "" {
+			log.Fatalf("-external-metrics-address cannot be used together with -shard-group")
+		}
+		if shardIdentity == "" {
+			if shardIdentity, err = os.Hostname(); err != nil {
+				log.Fatalf("failed to determine the shard identity: %s", err.Error())
+			}
+		}
+		shards = sharding.NewCoordinator(kubeClient, shardNamespace, shardGroup, shardIdentity,
+			time.Duration(shardLease)*time.Second)
+	}
+
+	var auditSinks []controller.AuditSink
+	if auditFile != "" {
+		fileSink, err := audit.NewFileSink(auditFile, int64(auditFileSize)*1024*1024, auditBackups)
+		if err != nil {
+			log.Fatalf("failed to open the audit file: %s", err.Error())
+		}
+		defer fileSink.Close()
+		auditSinks = append(auditSinks, fileSink)
+	}
+	var recordSink *audit.RecordSink
+	if auditRecords {
+		recordSink = audit.NewRecordSink(scalerClient, namespace, time.Duration(auditTTL)*time.Hour)
+		auditSinks = append(auditSinks, recordSink)
+	}
+
+	notifier := notify.NewNotifier(&http.Client{Timeout: 10 * time.Second}, notifyQueue, notifyAttempts, time.Second)
+
+	var publisher *cloudevents.Publisher
+	if eventsURL != "" {
+		publisher, err = cloudevents.NewPublisher(&http.Client{Timeout: 10 * time.Second}, eventsURL, eventsSource,
+			cloudevents.Mode(eventsMode), eventsBuffer)
+		if err != nil {
+			log.Fatalf("failed to create the CloudEvents publisher: %s", err.Error())
+		}
+	}
+
+	source, watchSource, err := newMetricsSource(metricsSource)
+	if err != nil {
+		log.Fatalf("invalid metrics source %q: %s", metricsSource, err.Error())
+	}
+
+	options := controller.Options{
+		DriftGracePeriod: time.Duration(driftGrace) * time.Second,
+		DryRun:           dryRun,
+		HistoryLength:    historyLength,
+		AuditSinks:       auditSinks,
+		Version:          version,
+		Notifier:         notifier,
+	}
+	if publisher != nil {
+		options.Publisher = publisher
+	}
+	if source != nil {
+		options.MetricsSource = source
+	}
+
 	controller := controller.NewController(kubeClient, scalerClient, scalerInformerFactory.Arjunnaik().V1alpha1().Scalers(),
-		podInformer, scaleGetter, mapper, prometheusClient, interval)
+		podInformer, hpaInformer, deploymentInformer, statefulSetInformer,
+		freezeInformer, scaleGetter, mapper, prometheusClient, shards, namespace, interval,
+		options)
+
+	var additionalPermissions []authorizationv1.ResourceAttributes
+	if auditRecords {
+		additionalPermissions = append(additionalPermissions, audit.RecordPermissions...)
+	}
+	if err := controller.WaitForPermissions(stopCh, additionalPermissions...); err != nil {
+		log.Fatalf("failed to wait for the required permissions: %s", err.Error())
+	}
 
 	go kubeInformerFactory.Start(stopCh)
 	go scalerInformerFactory.Start(stopCh)
+	go freezeInformerFactory.Start(stopCh)
+	// the Lease of the shard is released on shutdown before the process exits
+	shardsDone := make(chan struct{})
+	if shards != nil {
+		go func() {
+			shards.Run(stopCh)
+			close(shardsDone)
+		}()
+	} else {
+		close(shardsDone)
+	}
+	go notifier.Run(2, stopCh)
+	if publisher != nil {
+		go publisher.Run(stopCh)
+	}
+	if recordSink != nil {
+		go recordSink.Run(time.Hour, stopCh)
+	}
+	if watchSource != nil {
+		go watchSource(stopCh)
+	}
+	if dashboardAddr != "" {
+		if (dashboardCert == "") != (dashboardKey == "") {
+			log.Fatalf("-dashboard-tls-cert and -dashboard-tls-key have to be given together")
+		}
+		authenticator, authorizer, err := newAuthenticator(kubeClient, dashboardToken, dashboardCert != "")
+		if err != nil {
+			log.Fatalf("failed to set up the dashboard authentication: %s", err.Error())
+		}
+		listScalers := authorizationv1.ResourceAttributes{Group: scaler.GroupName, Resource: "scalers", Verb: "list"}
+		go serveMaybeTLS("dashboard", dashboardAddr, httpauth.Handler(authenticator, "simple-scaler",
+			httpauth.Require(authorizer, listScalers, dashboard.NewHandler(controller))), dashboardCert, dashboardKey, stopCh)
+	}
+	if apiAddr != "" {
+		if (apiCert == "") != (apiKey == "") {
+			log.Fatalf("-api-tls-cert and -api-tls-key have to be given together")
+		}
+		authenticator, authorizer, err := newAuthenticator(kubeClient, apiToken, apiCert != "")
+		if err != nil {
+			log.Fatalf("failed to set up the API authentication: %s", err.Error())
+		}
+		go serveMaybeTLS("API", apiAddr, httpauth.Handler(authenticator, "simple-scaler",
+			api.NewHandler(controller, controller.Explainer(), authorizer)), apiCert, apiKey, stopCh)
+	}
+	if metricsAddr != "" {
+		if metricsCert == "" || metricsKey == "" || metricsCA == "" {
+			log.Fatalf("the external metrics API requires -external-metrics-tls-cert, -external-metrics-tls-key and -external-metrics-client-ca")
+		}
+		// evaluations which missed a few resyncs are no longer served
+		go serveTLS("external metrics API", metricsAddr, externalmetrics.NewHandler(controller, 3*interval),
+			metricsCert, metricsKey, metricsCA, stopCh)
+	}
 
 	if err = controller.Run(2, stopCh); err != nil {
 		log.Fatalf("error running scaler controller: %v", err.Error())
 	}
+	<-shardsDone
 }
 
 func init() {
@@ -100,4 +307,38 @@ func init() {
 	flag.StringVar(&prometheusURL, "prometheus-url", "", "Address of the prometheus server")
 	flag.IntVar(&resyncInterval, "resync-interval", 30, "The resync interval for the controller in seconds")
 	flag.BoolVar(&debugLogging, "debug", false, "Print the debug logs")
+	flag.StringVar(&shardGroup, "shard-group", "", "Name of the shard group. When set the Scalers are partitioned between all the replicas in the group")
+	flag.StringVar(&shardNamespace, "shard-namespace", "kube-system", "Namespace of the Leases used for sharding")
+	flag.StringVar(&shardIdentity, "shard-identity", "", "Identity of this replica in the shard group. Defaults to the hostname")
+	flag.IntVar(&shardLease, "shard-lease-duration", 15, "Duration of the shard Lease in seconds")
+	flag.StringVar(&namespace, "namespace", metav1.NamespaceAll, "Only watch the Scalers and pods in this namespace. Defaults to all namespaces")
+	flag.StringVar(&scalerSelector, "scaler-selector", "", "Only watch the Scalers matching this label selector")
+	flag.BoolVar(&scalingFreezes, "scaling-freezes", true, "Watch the cluster scoped ScalingFreezes. Disable it to run with only a namespaced Role")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only record the recommended replicas in the status of the Scalers without changing the targets")
+	flag.IntVar(&historyLength, "history-length", 10, "Number of scaling decisions kept in the status of every Scaler. 0 disables the history")
+	flag.StringVar(&auditFile, "audit-file", "", "Append a JSON line to this file for every change to the replicas of a target")
+	flag.IntVar(&auditFileSize, "audit-file-max-size", 100, "Size in megabytes after which the audit file is rotated")
+	flag.IntVar(&auditBackups, "audit-file-max-backups", 5, "Number of rotated audit files to keep")
+	flag.BoolVar(&auditRecords, "audit-records", false, "Create a ScalingRecord for every change to the replicas of a target")
+	flag.IntVar(&auditTTL, "audit-record-ttl", 720, "Hours after which ScalingRecords are deleted. 0 keeps them forever")
+	flag.IntVar(&notifyQueue, "notification-queue-size", 100, "Number of notifications which can wait for delivery before new ones are dropped")
+	flag.IntVar(&notifyAttempts, "notification-attempts", 5, "Number of attempts to deliver a notification")
+	flag.StringVar(&eventsURL, "cloudevents-url", "", "Send the scaling decisions as CloudEvents to this URL")
+	flag.StringVar(&eventsMode, "cloudevents-mode", string(cloudevents.Binary), "Content mode of the CloudEvents. binary or structured")
+	flag.StringVar(&eventsSource, "cloudevents-source", "simple-scaler", "Source attribute of the CloudEvents")
+	flag.IntVar(&eventsBuffer, "cloudevents-buffer", 100, "Number of CloudEvents which can wait to be sent before new ones are dropped")
+	flag.StringVar(&metricsSource, "metrics-source", "prometheus", "Where the pod metrics come from. Either prometheus or file://<path>[?replay=true&poll=10s]")
+	flag.StringVar(&dashboardAddr, "dashboard-address", "", "Serve the dashboard on this address, e.g. :8080. The dashboard is disabled when empty")
+	flag.StringVar(&dashboardToken, "dashboard-token-file", "", "File with the token for the dashboard. Without it the Kubernetes token of a user who may list Scalers is required, which needs TLS")
+	flag.StringVar(&dashboardCert, "dashboard-tls-cert", "", "Certificate of the dashboard. The dashboard is served over plain HTTP without it")
+	flag.StringVar(&dashboardKey, "dashboard-tls-key", "", "Private key of the dashboard")
+	flag.StringVar(&apiAddr, "api-address", "", "Serve the HTTP API on this address, e.g. :8081. The API is disabled when empty")
+	flag.StringVar(&apiToken, "api-token-file", "", "File with the token for the API. Without it the Kubernetes token of a user who may get Scalers is required, which needs TLS")
+	flag.StringVar(&apiCert, "api-tls-cert", "", "Certificate of the API. The API is served over plain HTTP without it")
+	flag.StringVar(&apiKey, "api-tls-key", "", "Private key of the API")
+	flag.StringVar(&metricsAddr, "external-metrics-address", "", "Serve the external.metrics.k8s.io API on this address, e.g. :8443. The API is disabled when empty")
+	flag.StringVar(&metricsCert, "external-metrics-tls-cert", "", "Certificate of the external metrics API")
+	flag.StringVar(&metricsKey, "external-metrics-tls-key", "", "Private key of the external metrics API")
+	flag.StringVar(&metricsCA, "external-metrics-client-ca", "", "CA of the client certificates which may access the external metrics API. This is the requestheader CA of the API server")
+	flag.IntVar(&driftGrace, "drift-grace-period", 600, "How long a manual change to the replicas of a target is respected in seconds")
 }
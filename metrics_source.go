@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// newMetricsSource returns the metrics source selected with the -metrics-source flag and a
+// function which keeps it up to date, if it needs one. Prometheus is returned as nil so that
+// the controller creates it from the prometheus client.
+func newMetricsSource(value string) (replicacalculator.MetricsSource, func(stopCh <-chan struct{}), error) {
+	if value == "" || value == "prometheus" {
+		return nil, nil, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Scheme != "file" {
+		return nil, nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	query := u.Query()
+	replay := false
+	if r := query.Get("replay"); r != "" {
+		if replay, err = strconv.ParseBool(r); err != nil {
+			return nil, nil, fmt.Errorf("invalid replay: %v", err)
+		}
+	}
+	source, err := replicacalculator.NewFileMetricsSource(u.Host+u.Path, replay)
+	if err != nil {
+		return nil, nil, err
+	}
+	poll := query.Get("poll")
+	if poll == "" {
+		return source, nil, nil
+	}
+	interval, err := time.ParseDuration(poll)
+	if err != nil || interval <= 0 {
+		return nil, nil, fmt.Errorf("invalid poll interval %q", poll)
+	}
+	return source, func(stopCh <-chan struct{}) { source.Watch(interval, stopCh) }, nil
+}
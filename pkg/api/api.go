@@ -0,0 +1,367 @@
+// Package api serves the Scalers, their last decisions and on-demand recommendations as JSON
+// for other tools
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/arjunrn/simple-scaler/controller"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/httpauth"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	log "github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// Prefix is the path all the endpoints are served under
+	Prefix = "/api/v1/"
+
+	// maxBodySize limits the size of a posted spec
+	maxBodySize = 1 << 20
+)
+
+// Source provides the Scalers and their latest evaluations
+type Source interface {
+	Scalers() ([]*v1alpha1.Scaler, error)
+	Scaler(namespace, name string) (*v1alpha1.Scaler, error)
+	LastEvaluation(namespace, name string) (controller.Evaluation, bool)
+}
+
+// Evaluator evaluates Scalers on demand
+type Evaluator interface {
+	Explain(namespace, name string) (*controller.Explanation, error)
+	ExplainSpec(namespace string, spec v1alpha1.ScalerSpec) (*controller.Explanation, error)
+	// TargetResource returns the resource of the target whose scale subresource is read
+	TargetResource(target v1alpha1.ScaleTarget) (schema.GroupResource, error)
+}
+
+// Scaler is a Scaler with its last decision
+type Scaler struct {
+	Namespace       string               `json:"namespace"`
+	Name            string               `json:"name"`
+	Target          v1alpha1.ScaleTarget `json:"target"`
+	MinReplicas     int32                `json:"minReplicas"`
+	MaxReplicas     int32                `json:"maxReplicas"`
+	CurrentReplicas int32                `json:"currentReplicas"`
+	DesiredReplicas int32                `json:"desiredReplicas"`
+	// LastDecision is the latest change of the replicas in the history, whether it was applied
+	// or not
+	LastDecision *v1alpha1.ScalingDecision `json:"lastDecision,omitempty"`
+	// LastEvaluation is the latest evaluation by the replica of the controller which answered
+	LastEvaluation *Evaluation `json:"lastEvaluation,omitempty"`
+}
+
+// Evaluation is the outcome of the latest evaluation of a Scaler
+type Evaluation struct {
+	Time               time.Time                   `json:"time"`
+	CurrentReplicas    int32                       `json:"currentReplicas"`
+	DesiredReplicas    int32                       `json:"desiredReplicas"`
+	MinReplicas        int32                       `json:"minReplicas"`
+	MaxReplicas        int32                       `json:"maxReplicas"`
+	ScaleUpThreshold   int32                       `json:"scaleUpThreshold"`
+	ScaleDownThreshold int32                       `json:"scaleDownThreshold"`
+	Blocked            bool                        `json:"blocked"`
+	Reason             string                      `json:"reason"`
+	Utilization        v1alpha1.UtilizationSummary `json:"utilization"`
+	// Window is the average utilization of the pods at every sample of the metric window
+	Window []int32 `json:"window,omitempty"`
+}
+
+// Recommendation is the outcome of an on-demand evaluation
+type Recommendation struct {
+	Target             string                      `json:"target"`
+	CurrentReplicas    int32                       `json:"currentReplicas"`
+	DesiredReplicas    int32                       `json:"desiredReplicas"`
+	MinReplicas        int32                       `json:"minReplicas"`
+	MaxReplicas        int32                       `json:"maxReplicas"`
+	ScaleUpThreshold   int32                       `json:"scaleUpThreshold"`
+	ScaleDownThreshold int32                       `json:"scaleDownThreshold"`
+	Utilization        v1alpha1.UtilizationSummary `json:"utilization"`
+	Pods               []Pod                       `json:"pods,omitempty"`
+	Reason             string                      `json:"reason"`
+	// Gate is the check which keeps the desired replicas from being applied. It is empty when
+	// the controller would scale the target.
+	Gate  string   `json:"gate,omitempty"`
+	Notes []string `json:"notes,omitempty"`
+}
+
+// Pod are the samples of a pod and how they compare to the thresholds
+type Pod struct {
+	Name    string `json:"name"`
+	Samples []int  `json:"samples"`
+	Verdict string `json:"verdict"`
+}
+
+// Error is the body of a failed request
+type Error struct {
+	Error string `json:"error"`
+}
+
+// NewHandler returns the handler of the API. It serves
+//
+//	GET  /api/v1/scalers                                     all the Scalers
+//	GET  /api/v1/namespaces/<namespace>/scalers/<name>       a Scaler and its last decision
+//	POST /api/v1/namespaces/<namespace>/scalers/<name>/evaluate  a recommendation for the Scaler
+//	POST /api/v1/namespaces/<namespace>/recommendations      a recommendation for the posted ScalerSpec
+//
+// The handler has to be wrapped by httpauth.Handler. Listing requires the permission to list
+// Scalers in all namespaces, everything else the permission to get Scalers in the namespace of
+// the path. Evaluations also require the permission to get the scale of the target, which the
+// controller reads on behalf of the user.
+func NewHandler(source Source, evaluator Evaluator, authorizer httpauth.Authorizer) http.Handler {
+	return &handler{source: source, evaluator: evaluator, authorizer: authorizer}
+}
+
+type handler struct {
+	source     Source
+	evaluator  Evaluator
+	authorizer httpauth.Authorizer
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, Prefix) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, Prefix), "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "scalers":
+		if allowMethod(w, r, http.MethodGet) && h.authorize(w, r, scalersPermission("list", "", "")) {
+			h.listScalers(w)
+		}
+	case len(parts) == 4 && parts[0] == "namespaces" && parts[2] == "scalers":
+		if allowMethod(w, r, http.MethodGet) && h.authorize(w, r, scalersPermission("get", parts[1], parts[3])) {
+			h.getScaler(w, parts[1], parts[3])
+		}
+	case len(parts) == 5 && parts[0] == "namespaces" && parts[2] == "scalers" && parts[4] == "evaluate":
+		if allowMethod(w, r, http.MethodPost) && h.authorize(w, r, scalersPermission("get", parts[1], parts[3])) {
+			h.evaluateScaler(w, r, parts[1], parts[3])
+		}
+	case len(parts) == 3 && parts[0] == "namespaces" && parts[2] == "recommendations":
+		if allowMethod(w, r, http.MethodPost) && h.authorize(w, r, scalersPermission("get", parts[1], "")) {
+			h.recommend(w, r, parts[1])
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *handler) listScalers(w http.ResponseWriter) {
+	scalers, err := h.source.Scalers()
+	if err != nil {
+		log.Errorf("failed to list the Scalers for the API: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list the Scalers")
+		return
+	}
+	result := make([]Scaler, len(scalers))
+	for i, s := range scalers {
+		result[i] = h.scaler(s)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *handler) getScaler(w http.ResponseWriter, namespace, name string) {
+	s, err := h.source.Scaler(namespace, name)
+	if errors.IsNotFound(err) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Errorf("failed to get the Scaler %s/%s for the API: %v", namespace, name, err)
+		writeError(w, http.StatusInternalServerError, "failed to get the Scaler")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.scaler(s))
+}
+
+func (h *handler) evaluateScaler(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	s, err := h.source.Scaler(namespace, name)
+	if errors.IsNotFound(err) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Errorf("failed to get the Scaler %s/%s for the API: %v", namespace, name, err)
+		writeError(w, http.StatusInternalServerError, "failed to get the Scaler")
+		return
+	}
+	if !h.authorizeTarget(w, r, namespace, s.Spec.Target) {
+		return
+	}
+	explanation, err := h.evaluator.Explain(namespace, name)
+	if errors.IsNotFound(err) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, newRecommendation(explanation))
+}
+
+func (h *handler) recommend(w http.ResponseWriter, r *http.Request, namespace string) {
+	var spec v1alpha1.ScalerSpec
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid ScalerSpec: "+err.Error())
+		return
+	}
+	if err := controller.ValidateSpec(spec); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid ScalerSpec: "+err.Error())
+		return
+	}
+	// the controller would run the query or call the address with its own credentials
+	if spec.ScaleToZero != nil && spec.ScaleToZero.ActivationQuery != "" {
+		writeError(w, http.StatusBadRequest, "invalid ScalerSpec: scaleToZero.activationQuery is not supported in recommendations")
+		return
+	}
+	if spec.ExternalScaler != nil {
+		writeError(w, http.StatusBadRequest, "invalid ScalerSpec: externalScaler is not supported in recommendations")
+		return
+	}
+	if !h.authorizeTarget(w, r, namespace, spec.Target) {
+		return
+	}
+	explanation, err := h.evaluator.ExplainSpec(namespace, spec)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, newRecommendation(explanation))
+}
+
+// authorize returns true if the user of the request has the permission. Otherwise it writes the
+// error.
+func (h *handler) authorize(w http.ResponseWriter, r *http.Request, permission authorizationv1.ResourceAttributes) bool {
+	user, _ := httpauth.UserFrom(r.Context())
+	switch err := h.authorizer.Authorize(user, permission); err {
+	case nil:
+		return true
+	case httpauth.ErrForbidden:
+		log.Infof("denied %s %s to %s", r.Method, r.URL.Path, user.Name)
+		writeError(w, http.StatusForbidden, fmt.Sprintf("%s may not %s %s", user.Name, permission.Verb, describe(permission)))
+	default:
+		log.Errorf("failed to authorize %s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, http.StatusInternalServerError, "authorization failed")
+	}
+	return false
+}
+
+// authorizeTarget returns true if the user of the request may get the scale of the target
+func (h *handler) authorizeTarget(w http.ResponseWriter, r *http.Request, namespace string, target v1alpha1.ScaleTarget) bool {
+	resource, err := h.evaluator.TargetResource(target)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown target %s %s: %v", target.APIVersion, target.Kind, err))
+		return false
+	}
+	return h.authorize(w, r, authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "get",
+		Group: resource.Group, Resource: resource.Resource, Subresource: "scale", Name: target.Name})
+}
+
+func scalersPermission(verb, namespace, name string) authorizationv1.ResourceAttributes {
+	return authorizationv1.ResourceAttributes{Namespace: namespace, Verb: verb, Group: scaler.GroupName,
+		Resource: "scalers", Name: name}
+}
+
+func describe(permission authorizationv1.ResourceAttributes) string {
+	resource := permission.Resource
+	if permission.Group != "" {
+		resource += "." + permission.Group
+	}
+	if permission.Subresource != "" {
+		resource += "/" + permission.Subresource
+	}
+	if permission.Name != "" {
+		resource += " " + permission.Name
+	}
+	if permission.Namespace == "" {
+		return resource + " in all namespaces"
+	}
+	return resource + " in " + permission.Namespace
+}
+
+func (h *handler) scaler(s *v1alpha1.Scaler) Scaler {
+	result := Scaler{
+		Namespace:       s.Namespace,
+		Name:            s.Name,
+		Target:          s.Spec.Target,
+		MinReplicas:     s.Spec.MinReplicas,
+		MaxReplicas:     s.Spec.MaxReplicas,
+		CurrentReplicas: s.Status.CurrentReplicas,
+		DesiredReplicas: s.Status.DesiredReplicas,
+	}
+	if n := len(s.Status.History); n > 0 {
+		result.LastDecision = s.Status.History[n-1].DeepCopy()
+	}
+	if e, ok := h.source.LastEvaluation(s.Namespace, s.Name); ok {
+		result.LastEvaluation = &Evaluation{
+			Time:               e.Time,
+			CurrentReplicas:    e.CurrentReplicas,
+			DesiredReplicas:    e.DesiredReplicas,
+			MinReplicas:        e.MinReplicas,
+			MaxReplicas:        e.MaxReplicas,
+			ScaleUpThreshold:   e.ScaleUpThreshold,
+			ScaleDownThreshold: e.ScaleDownThreshold,
+			Blocked:            e.Blocked,
+			Reason:             e.Reason,
+			Utilization:        utilizationSummary(e.Utilization),
+			Window:             e.Window,
+		}
+	}
+	return result
+}
+
+func newRecommendation(x *controller.Explanation) Recommendation {
+	r := Recommendation{
+		Target:             x.Target,
+		CurrentReplicas:    x.CurrentReplicas,
+		DesiredReplicas:    x.DesiredReplicas,
+		MinReplicas:        x.MinReplicas,
+		MaxReplicas:        x.MaxReplicas,
+		ScaleUpThreshold:   x.ScaleUpThreshold,
+		ScaleDownThreshold: x.ScaleDownThreshold,
+		Utilization:        utilizationSummary(x.Utilization),
+		Reason:             x.Reason,
+		Gate:               string(x.Gate),
+		Notes:              x.Notes,
+	}
+	for _, p := range x.Pods {
+		r.Pods = append(r.Pods, Pod{Name: p.Name, Samples: p.Samples, Verdict: p.Verdict})
+	}
+	return r
+}
+
+func utilizationSummary(u replicacalculator.Utilization) v1alpha1.UtilizationSummary {
+	return v1alpha1.UtilizationSummary{Average: u.Average, Min: u.Min, Max: u.Max, Pods: u.Pods}
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, Error{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Errorf("failed to write the API response: %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/arjunrn/simple-scaler/controller"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/httpauth"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var resource = schema.GroupResource{Group: "arjunnaik.in", Resource: "scalers"}
+
+type fakeSource struct {
+	scalers     []*v1alpha1.Scaler
+	evaluations map[string]controller.Evaluation
+}
+
+func (f *fakeSource) Scalers() ([]*v1alpha1.Scaler, error) {
+	return f.scalers, nil
+}
+
+func (f *fakeSource) Scaler(namespace, name string) (*v1alpha1.Scaler, error) {
+	for _, s := range f.scalers {
+		if s.Namespace == namespace && s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, errors.NewNotFound(resource, name)
+}
+
+func (f *fakeSource) LastEvaluation(namespace, name string) (controller.Evaluation, bool) {
+	e, ok := f.evaluations[namespace+"/"+name]
+	return e, ok
+}
+
+type fakeEvaluator struct {
+	specs []v1alpha1.ScalerSpec
+}
+
+func (f *fakeEvaluator) Explain(namespace, name string) (*controller.Explanation, error) {
+	if name != "web" {
+		return nil, errors.NewNotFound(resource, name)
+	}
+	return &controller.Explanation{Target: "Deployment/web", CurrentReplicas: 3, DesiredReplicas: 4,
+		Utilization: replicacalculator.Utilization{Average: 80, Min: 75, Max: 85, Pods: 3},
+		Pods:        []controller.PodExplanation{{Name: "web-1", Samples: []int{80, 85}, Verdict: controller.VerdictAbove}},
+		Reason:      "utilization above 70%", Gate: controller.GateCooldown}, nil
+}
+
+func (f *fakeEvaluator) ExplainSpec(namespace string, spec v1alpha1.ScalerSpec) (*controller.Explanation, error) {
+	f.specs = append(f.specs, spec)
+	if spec.Target.Name == "broken" {
+		return nil, fmt.Errorf("failed to get the metrics")
+	}
+	return &controller.Explanation{Target: spec.Target.Kind + "/" + spec.Target.Name, CurrentReplicas: 2,
+		DesiredReplicas: 2, MinReplicas: spec.MinReplicas, MaxReplicas: spec.MaxReplicas,
+		Reason: "utilization within the thresholds"}, nil
+}
+
+func (f *fakeEvaluator) TargetResource(target v1alpha1.ScaleTarget) (schema.GroupResource, error) {
+	switch target.Kind {
+	case "Deployment":
+		return schema.GroupResource{Group: "apps", Resource: "deployments"}, nil
+	case "StatefulSet":
+		return schema.GroupResource{Group: "apps", Resource: "statefulsets"}, nil
+	}
+	return schema.GroupResource{}, fmt.Errorf("no matches for kind %q", target.Kind)
+}
+
+// fakeAuthorizer records the permissions it was asked for and denies the ones in denied
+type fakeAuthorizer struct {
+	checked []string
+	denied  map[string]bool
+}
+
+func (f *fakeAuthorizer) Authorize(user httpauth.User, permission authorizationv1.ResourceAttributes) error {
+	description := permission.Verb + " " + describe(permission)
+	f.checked = append(f.checked, description)
+	if f.denied[description] {
+		return httpauth.ErrForbidden
+	}
+	return nil
+}
+
+func newTestHandler() (http.Handler, *fakeEvaluator) {
+	h, evaluator, _ := newAuthorizedTestHandler()
+	return h, evaluator
+}
+
+func newAuthorizedTestHandler() (http.Handler, *fakeEvaluator, *fakeAuthorizer) {
+	now := time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC)
+	source := &fakeSource{
+		scalers: []*v1alpha1.Scaler{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+				Spec: v1alpha1.ScalerSpec{MinReplicas: 1, MaxReplicas: 10,
+					Target: v1alpha1.ScaleTarget{Kind: "Deployment", Name: "web"}},
+				Status: v1alpha1.ScalerStatus{CurrentReplicas: 3, DesiredReplicas: 3, History: []v1alpha1.ScalingDecision{
+					{Time: metav1.NewTime(now.Add(-10 * time.Minute)), FromReplicas: 2, ToReplicas: 3, Reason: "busy"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "worker"},
+				Spec:       v1alpha1.ScalerSpec{Target: v1alpha1.ScaleTarget{Kind: "StatefulSet", Name: "worker"}},
+			},
+		},
+		evaluations: map[string]controller.Evaluation{
+			"default/web": {Time: now, CurrentReplicas: 3, DesiredReplicas: 4, ScaleUpThreshold: 70,
+				Reason:      "utilization above 70%",
+				Utilization: replicacalculator.Utilization{Average: 80, Min: 75, Max: 85, Pods: 3},
+				Window:      []int32{60, 80}},
+		},
+	}
+	evaluator := &fakeEvaluator{}
+	authorizer := &fakeAuthorizer{denied: map[string]bool{}}
+	return NewHandler(source, evaluator, authorizer), evaluator, authorizer
+}
+
+func TestListScalers(t *testing.T) {
+	h, _ := newTestHandler()
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/scalers", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var scalers []Scaler
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &scalers))
+	assert.Len(t, scalers, 2)
+	assert.Equal(t, "web", scalers[0].Name)
+	assert.NotNil(t, scalers[0].LastDecision)
+	assert.Equal(t, int32(3), scalers[0].LastDecision.ToReplicas)
+	assert.NotNil(t, scalers[0].LastEvaluation)
+	assert.Equal(t, int32(4), scalers[0].LastEvaluation.DesiredReplicas)
+	assert.Equal(t, int32(80), scalers[0].LastEvaluation.Utilization.Average)
+	assert.Equal(t, []int32{60, 80}, scalers[0].LastEvaluation.Window)
+	assert.Equal(t, "worker", scalers[1].Name)
+	assert.Nil(t, scalers[1].LastDecision)
+	assert.Nil(t, scalers[1].LastEvaluation)
+}
+
+func TestGetScaler(t *testing.T) {
+	h, _ := newTestHandler()
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"found", http.MethodGet, "/api/v1/namespaces/default/scalers/web", http.StatusOK, `"lastDecision":{`},
+		{"trailing slash", http.MethodGet, "/api/v1/namespaces/default/scalers/web/", http.StatusOK, `"name":"web"`},
+		{"missing", http.MethodGet, "/api/v1/namespaces/default/scalers/api", http.StatusNotFound, `"error":`},
+		{"wrong namespace", http.MethodGet, "/api/v1/namespaces/batch/scalers/web", http.StatusNotFound, `"error":`},
+		{"wrong method", http.MethodDelete, "/api/v1/namespaces/default/scalers/web", http.StatusMethodNotAllowed, `"error":`},
+		{"unknown path", http.MethodGet, "/api/v1/deployments", http.StatusNotFound, `"error":`},
+		{"outside of the prefix", http.MethodGet, "/scalers", http.StatusNotFound, `"error":`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.path, nil))
+			assert.Equal(t, tc.status, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tc.body)
+		})
+	}
+}
+
+func TestEvaluateScaler(t *testing.T) {
+	h, _ := newTestHandler()
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/namespaces/default/scalers/web/evaluate", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var recommendation Recommendation
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &recommendation))
+	assert.Equal(t, int32(4), recommendation.DesiredReplicas)
+	assert.Equal(t, string(controller.GateCooldown), recommendation.Gate)
+	assert.Equal(t, []Pod{{Name: "web-1", Samples: []int{80, 85}, Verdict: controller.VerdictAbove}}, recommendation.Pods)
+
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/namespaces/default/scalers/api/evaluate", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/scalers/web/evaluate", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, http.MethodPost, recorder.Header().Get("Allow"))
+}
+
+func TestRecommend(t *testing.T) {
+	valid := `{"target":{"kind":"Deployment","name":"web","apiVersion":"apps/v1"},"evaluations":2,` +
+		`"minReplicas":1,"maxReplicas":5,"scaleUp":70,"scaleDown":20}`
+	for _, tc := range []struct {
+		name   string
+		body   string
+		status int
+		result string
+	}{
+		{"valid spec", valid, http.StatusOK, `"target":"Deployment/web"`},
+		{"invalid json", `{"target":`, http.StatusBadRequest, `invalid ScalerSpec`},
+		{"unknown field", `{"replicas":3}`, http.StatusBadRequest, `unknown field`},
+		{"invalid spec", strings.Replace(valid, `"minReplicas":1`, `"minReplicas":7`, 1), http.StatusBadRequest,
+			`invalid ScalerSpec`},
+		{"failed evaluation", strings.Replace(valid, `"name":"web"`, `"name":"broken"`, 1),
+			http.StatusInternalServerError, `failed to get the metrics`},
+		{"activation query", strings.Replace(valid, `"evaluations":2`,
+			`"evaluations":2,"scaleToZero":{"activationQuery":"up","idleSeconds":60}`, 1),
+			http.StatusBadRequest, `activationQuery is not supported`},
+		{"external scaler", strings.Replace(valid, `"evaluations":2`,
+			`"evaluations":2,"externalScaler":{"address":"169.254.169.254:80"}`, 1),
+			http.StatusBadRequest, `externalScaler is not supported`},
+		{"unknown target kind", strings.Replace(valid, `"kind":"Deployment"`, `"kind":"Rollout"`, 1),
+			http.StatusBadRequest, `unknown target`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, evaluator := newTestHandler()
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/namespaces/default/recommendations",
+				strings.NewReader(tc.body)))
+			assert.Equal(t, tc.status, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tc.result)
+			if tc.status == http.StatusOK {
+				assert.Len(t, evaluator.specs, 1)
+				assert.Equal(t, int32(5), evaluator.specs[0].MaxReplicas)
+			}
+		})
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	spec := `{"target":{"kind":"Deployment","name":"api","apiVersion":"apps/v1"},"evaluations":2,` +
+		`"minReplicas":1,"maxReplicas":5,"scaleUp":70,"scaleDown":20}`
+	for _, tc := range []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		denied  string
+		status  int
+		checked []string
+	}{
+		{name: "list", method: http.MethodGet, path: "/api/v1/scalers", status: http.StatusOK,
+			checked: []string{"list scalers.arjunnaik.in in all namespaces"}},
+		{name: "list denied", method: http.MethodGet, path: "/api/v1/scalers",
+			denied: "list scalers.arjunnaik.in in all namespaces", status: http.StatusForbidden,
+			checked: []string{"list scalers.arjunnaik.in in all namespaces"}},
+		{name: "get in the namespace of the path", method: http.MethodGet, path: "/api/v1/namespaces/batch/scalers/worker",
+			status: http.StatusOK, checked: []string{"get scalers.arjunnaik.in worker in batch"}},
+		{name: "get denied", method: http.MethodGet, path: "/api/v1/namespaces/batch/scalers/worker",
+			denied: "get scalers.arjunnaik.in worker in batch", status: http.StatusForbidden,
+			checked: []string{"get scalers.arjunnaik.in worker in batch"}},
+		{name: "evaluate", method: http.MethodPost, path: "/api/v1/namespaces/default/scalers/web/evaluate",
+			status: http.StatusOK, checked: []string{"get scalers.arjunnaik.in web in default",
+				"get deployments.apps/scale web in default"}},
+		{name: "evaluate without access to the target", method: http.MethodPost,
+			path: "/api/v1/namespaces/default/scalers/web/evaluate", denied: "get deployments.apps/scale web in default",
+			status: http.StatusForbidden, checked: []string{"get scalers.arjunnaik.in web in default",
+				"get deployments.apps/scale web in default"}},
+		{name: "recommend", method: http.MethodPost, path: "/api/v1/namespaces/team-a/recommendations", body: spec,
+			status: http.StatusOK, checked: []string{"get scalers.arjunnaik.in in team-a",
+				"get deployments.apps/scale api in team-a"}},
+		{name: "recommend without access to the target", method: http.MethodPost,
+			path: "/api/v1/namespaces/team-a/recommendations", body: spec, denied: "get deployments.apps/scale api in team-a",
+			status: http.StatusForbidden, checked: []string{"get scalers.arjunnaik.in in team-a",
+				"get deployments.apps/scale api in team-a"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, evaluator, authorizer := newAuthorizedTestHandler()
+			if tc.denied != "" {
+				authorizer.denied[tc.denied] = true
+			}
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.status, recorder.Code)
+			assert.Equal(t, tc.checked, authorizer.checked)
+			if tc.status == http.StatusForbidden {
+				assert.Empty(t, evaluator.specs, "nothing is evaluated for a user without permission")
+			}
+		})
+	}
+}
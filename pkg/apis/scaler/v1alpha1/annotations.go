@@ -0,0 +1,19 @@
+package v1alpha1
+
+const (
+	// PausedAnnotation stops all scaling of the target while it is set to "true"
+	PausedAnnotation = "arjunnaik.in/paused"
+	// DisabledAnnotation turns off the Scaler entirely while it is set to "true". Unlike a paused
+	// Scaler a disabled one does not check for conflicts or record anything in its status.
+	DisabledAnnotation = "arjunnaik.in/disabled"
+	// PinReplicasAnnotation holds the target at a fixed number of replicas, e.g. "5 until=2019-01-02T15:04:05Z"
+	PinReplicasAnnotation = "arjunnaik.in/pin-replicas"
+	// MinOverrideAnnotation replaces spec.minReplicas, e.g. "4 until=2019-01-02T15:04:05Z"
+	MinOverrideAnnotation = "arjunnaik.in/min-override"
+	// MaxOverrideAnnotation replaces spec.maxReplicas, e.g. "20 until=2019-01-02T15:04:05Z"
+	MaxOverrideAnnotation = "arjunnaik.in/max-override"
+	// ManualOverrideAnnotation is set on the Scaler when the replicas of the target were changed
+	// outside of the controller. It holds the time the change was detected. Scaling resumes when
+	// the grace period has passed or when the annotation is removed.
+	ManualOverrideAnnotation = "arjunnaik.in/manual-override"
+)
@@ -23,7 +23,8 @@ var (
 )
 
 func addKnownTypes(scheme *runtime.Scheme) error {
-	scheme.AddKnownTypes(SchemeGroupVersion, &Scaler{}, &ScalerList{})
+	scheme.AddKnownTypes(SchemeGroupVersion, &Scaler{}, &ScalerList{}, &ScalingRecord{}, &ScalingRecordList{},
+		&ScalingFreeze{}, &ScalingFreezeList{})
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
 }
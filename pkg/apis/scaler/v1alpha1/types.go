@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -27,6 +29,171 @@ type ScalerSpec struct {
 	Evaluations   int32       `json:"evaluations"`
 	ScaleUpSize   int32       `json:"scaleUpSize"`
 	ScaleDownSize int32       `json:"scaleDownSize"`
+	// OnDelete decides what happens to the target when the Scaler is deleted. The target is
+	// left as it is when it is not set.
+	OnDelete *OnDelete `json:"onDelete,omitempty"`
+	// DriftGracePeriodSeconds is how long a manual change to the replicas of the target is
+	// respected before the controller resumes scaling. Defaults to the controller setting.
+	DriftGracePeriodSeconds *int32 `json:"driftGracePeriodSeconds,omitempty"`
+	// DryRun makes the controller only record the replicas it would set in the status
+	// without changing the target
+	DryRun bool `json:"dryRun,omitempty"`
+	// Notifications are the HTTP endpoints which are told about scaling actions
+	Notifications []Notification `json:"notifications,omitempty"`
+	// Schedules change the bounds and the thresholds at recurring times. When several are
+	// active the first one in the list applies.
+	Schedules []Schedule `json:"schedules,omitempty"`
+	// BlackoutWindows are times during which the target is not scaled in some or all directions
+	BlackoutWindows []FreezeWindow `json:"blackoutWindows,omitempty"`
+	// ScaleToZero lets the target go down to zero replicas when it is idle and brings it back
+	// when there is traffic again. minReplicas has to be 0 for it to scale to zero.
+	ScaleToZero *ScaleToZero `json:"scaleToZero,omitempty"`
+	// Predictive scales the target ahead of the demand forecast from the history of its
+	// utilization. The reactive scaling still applies and the forecast can only add replicas.
+	Predictive *Predictive `json:"predictive,omitempty"`
+	// ExternalScaler provides the metrics of the target instead of the metrics source of the
+	// controller
+	ExternalScaler *ExternalScaler `json:"externalScaler,omitempty"`
+}
+
+// ExternalScaler is a gRPC service which provides the metrics of a target, e.g. the depth of a
+// queue. It implements the protocol of the external scalers of KEDA.
+// +k8s:deepcopy-gen=true
+type ExternalScaler struct {
+	// Address is the host and port of the external scaler
+	Address string `json:"address"`
+	// Metadata is passed to the external scaler with every call
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Seasonality is the length of the cycle in the demand of a target
+type Seasonality string
+
+const (
+	SeasonalityDaily  Seasonality = "Daily"
+	SeasonalityWeekly Seasonality = "Weekly"
+)
+
+// Predictive configures the forecast of the demand of a target
+// +k8s:deepcopy-gen=true
+type Predictive struct {
+	// HistoryDays is how many days of history the forecast is based on. It has to cover two
+	// cycles of the seasonality. Defaults to 7 for Daily and to 14 for Weekly.
+	HistoryDays int32 `json:"historyDays,omitempty"`
+	// Seasonality is the cycle of the demand. Defaults to Daily.
+	Seasonality Seasonality `json:"seasonality,omitempty"`
+	// HorizonSeconds is how far ahead the target is scaled for. Defaults to 600.
+	HorizonSeconds int32 `json:"horizonSeconds,omitempty"`
+	// MinConfidence is the confidence in percent below which the forecast is ignored. Defaults to 50.
+	MinConfidence *int32 `json:"minConfidence,omitempty"`
+}
+
+// ScaleToZero configures scaling to and from zero replicas. Without pods there is no
+// utilization to measure, so an activation query which does not depend on the pods, e.g. the
+// request rate at the ingress, decides whether the target is in use.
+// +k8s:deepcopy-gen=true
+type ScaleToZero struct {
+	// ActivationQuery is a PromQL query. The sum of its results is the activation value.
+	ActivationQuery string `json:"activationQuery"`
+	// ActivationThreshold is the value above which the target is considered to be in use
+	ActivationThreshold resource.Quantity `json:"activationThreshold,omitempty"`
+	// ActivationReplicas are the replicas the target is scaled to from zero. Defaults to 1.
+	ActivationReplicas int32 `json:"activationReplicas,omitempty"`
+	// IdleSeconds is how long the activation value has to stay at or below the threshold
+	// before the target is scaled to zero
+	IdleSeconds int32 `json:"idleSeconds"`
+}
+
+// Schedule overrides some of the settings of the Scaler while it is active
+// +k8s:deepcopy-gen=true
+type Schedule struct {
+	Name string `json:"name"`
+	// Cron is a five field cron expression of the times the schedule starts
+	Cron string `json:"cron"`
+	// TimeZone is the IANA time zone of the cron expression, e.g. Europe/Berlin. Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+	// DurationSeconds is how long the schedule stays active after every start
+	DurationSeconds int32  `json:"durationSeconds"`
+	MinReplicas     *int32 `json:"minReplicas,omitempty"`
+	MaxReplicas     *int32 `json:"maxReplicas,omitempty"`
+	ScaleUp         *int32 `json:"scaleUp,omitempty"`
+	ScaleDown       *int32 `json:"scaleDown,omitempty"`
+}
+
+// FreezeDirection is the scaling direction blocked by a freeze
+type FreezeDirection string
+
+const (
+	FreezeUp   FreezeDirection = "Up"
+	FreezeDown FreezeDirection = "Down"
+	FreezeAll  FreezeDirection = "All"
+)
+
+// FreezeWindow is a time during which scaling is blocked. It is either a fixed range between
+// Start and End or recurring with a Cron expression and a duration.
+// +k8s:deepcopy-gen=true
+type FreezeWindow struct {
+	Name  string       `json:"name,omitempty"`
+	Start *metav1.Time `json:"start,omitempty"`
+	End   *metav1.Time `json:"end,omitempty"`
+	// Cron is a five field cron expression of the times the window starts
+	Cron string `json:"cron,omitempty"`
+	// TimeZone is the IANA time zone of the cron expression. Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+	// DurationSeconds is how long the window stays open after every start of the cron expression
+	DurationSeconds int32 `json:"durationSeconds,omitempty"`
+	// Direction is the blocked direction. Defaults to All.
+	Direction FreezeDirection `json:"direction,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// NotificationEvent is a kind of event which can be sent to a notification endpoint
+type NotificationEvent string
+
+const (
+	// NotifyScaledUp is sent after the target was scaled up
+	NotifyScaledUp NotificationEvent = "ScaledUp"
+	// NotifyScaledDown is sent after the target was scaled down
+	NotifyScaledDown NotificationEvent = "ScaledDown"
+	// NotifyBoundReached is sent when the target needs more than the maximum or less than the
+	// minimum replicas
+	NotifyBoundReached NotificationEvent = "BoundReached"
+	// NotifyFailure is sent when the Scaler failed repeatedly
+	NotifyFailure NotificationEvent = "Failure"
+)
+
+// Notification is an HTTP endpoint which receives a POST with a JSON body for every event
+// +k8s:deepcopy-gen=true
+type Notification struct {
+	URL string `json:"url"`
+	// Events are the events sent to the endpoint. All events are sent when it is empty.
+	Events []NotificationEvent `json:"events,omitempty"`
+	// Template is a Go template which renders the body from the event. The strings of the
+	// event are escaped for a JSON string. The event itself is sent as JSON when it is empty.
+	Template string `json:"template,omitempty"`
+	// SecretRef selects the key in a Secret in the namespace of the Scaler which is used to
+	// sign the body with HMAC-SHA256
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
+}
+
+// OnDeletePolicy is the action taken on the target when the Scaler is deleted
+type OnDeletePolicy string
+
+const (
+	// OnDeleteRetain leaves the target with the replicas it has at the time of the deletion
+	OnDeleteRetain OnDeletePolicy = "Retain"
+	// OnDeleteRestore sets the target back to the replicas it had when the Scaler was created
+	OnDeleteRestore OnDeletePolicy = "Restore"
+	// OnDeleteFixed sets the target to a fixed number of replicas
+	OnDeleteFixed OnDeletePolicy = "Fixed"
+)
+
+// OnDelete is the deletion policy of the Scaler
+// +k8s:deepcopy-gen=true
+type OnDelete struct {
+	Policy OnDeletePolicy `json:"policy"`
+	// Replicas is only used with the Fixed policy
+	Replicas int32 `json:"replicas,omitempty"`
 }
 
 // ScalerStatus is the status of the Scaler
@@ -35,6 +202,132 @@ type ScalerStatus struct {
 	Condition            string `json:"condition"`
 	LastScalingTimestamp string `json:"lastScalingTimestamp"`
 	CurrentReplicas      int32  `json:"currentReplicas"`
+	// OriginalReplicas are the replicas the target had when the Scaler first processed it
+	OriginalReplicas *int32 `json:"originalReplicas,omitempty"`
+	// Conditions are the latest observations of the Scaler's state
+	Conditions []ScalerCondition `json:"conditions,omitempty"`
+	// LastAppliedReplicas are the replicas the controller last set on the target
+	LastAppliedReplicas *int32 `json:"lastAppliedReplicas,omitempty"`
+	// Overrides are the override annotations currently in effect
+	Overrides []ScalerOverride `json:"overrides,omitempty"`
+	// DesiredReplicas are the replicas computed in the last evaluation
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+	// Reason explains the outcome of the last evaluation
+	Reason string `json:"reason,omitempty"`
+	// History holds the most recent scaling decisions, oldest first
+	History []ScalingDecision `json:"history,omitempty"`
+	// ActiveSchedule is the name of the schedule which is currently applied
+	ActiveSchedule string `json:"activeSchedule,omitempty"`
+	// IdleSince is when the activation value last dropped to or below the threshold. It is not
+	// set while the target is in use.
+	IdleSince *metav1.Time `json:"idleSince,omitempty"`
+	// Forecast is the latest demand forecast of a predictive Scaler
+	Forecast *Forecast `json:"forecast,omitempty"`
+}
+
+// Forecast is the forecast demand of a target
+// +k8s:deepcopy-gen=true
+type Forecast struct {
+	// Time is when the forecast was made
+	Time metav1.Time `json:"time"`
+	// Demand is the highest forecast CPU usage within the horizon in percent of the request of
+	// a single pod, i.e. 250 is the usage of two and a half fully used pods
+	Demand int32 `json:"demand"`
+	// Replicas are needed to keep the utilization of every pod below the scale up threshold
+	Replicas int32 `json:"replicas"`
+	// Confidence in percent is how well the model describes the history
+	Confidence int32 `json:"confidence"`
+}
+
+// ScalingDirection is the direction of a scaling decision
+type ScalingDirection string
+
+const (
+	ScalingUp   ScalingDirection = "Up"
+	ScalingDown ScalingDirection = "Down"
+)
+
+// ScalingDecision records one decision to change the replicas of the target, whether it was
+// applied or not
+// +k8s:deepcopy-gen=true
+type ScalingDecision struct {
+	Time         metav1.Time      `json:"time"`
+	FromReplicas int32            `json:"fromReplicas"`
+	ToReplicas   int32            `json:"toReplicas"`
+	Direction    ScalingDirection `json:"direction"`
+	// Utilization is the aggregated utilization of the pods the decision was based on
+	Utilization UtilizationSummary `json:"utilization"`
+	// ScaleUpThreshold and ScaleDownThreshold are the utilization thresholds in percent
+	ScaleUpThreshold   int32 `json:"scaleUpThreshold"`
+	ScaleDownThreshold int32 `json:"scaleDownThreshold"`
+	// MinReplicas and MaxReplicas are the bounds in effect, including overrides
+	MinReplicas int32  `json:"minReplicas"`
+	MaxReplicas int32  `json:"maxReplicas"`
+	Reason      string `json:"reason"`
+	// Blocked is true when the decision was not applied because of the bounds or the cooldown
+	Blocked bool `json:"blocked,omitempty"`
+	// DryRun is true when the decision was only recommended
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// UtilizationSummary aggregates the utilization samples of the pods of a target in percent
+// +k8s:deepcopy-gen=true
+type UtilizationSummary struct {
+	// Average is the average of all the samples
+	Average int32 `json:"average"`
+	// Min and Max are the lowest and the highest average of a single pod
+	Min int32 `json:"min"`
+	Max int32 `json:"max"`
+	// Pods is the number of pods which had samples
+	Pods int32 `json:"pods"`
+}
+
+// ScalerOverrideType is the kind of override set through an annotation on the Scaler
+type ScalerOverrideType string
+
+const (
+	// OverridePaused stops all scaling
+	OverridePaused ScalerOverrideType = "Paused"
+	// OverridePinReplicas holds the target at a fixed number of replicas
+	OverridePinReplicas ScalerOverrideType = "PinReplicas"
+	// OverrideMinReplicas replaces the minimum replicas
+	OverrideMinReplicas ScalerOverrideType = "MinReplicas"
+	// OverrideMaxReplicas replaces the maximum replicas
+	OverrideMaxReplicas ScalerOverrideType = "MaxReplicas"
+)
+
+// ScalerOverride is an override which is in effect
+// +k8s:deepcopy-gen=true
+type ScalerOverride struct {
+	Type     ScalerOverrideType `json:"type"`
+	Replicas *int32             `json:"replicas,omitempty"`
+	// Until is when the override expires. It does not expire when it is not set.
+	Until *metav1.Time `json:"until,omitempty"`
+}
+
+// ScalerConditionType is the type of a Scaler condition
+type ScalerConditionType string
+
+const (
+	// ScalerConflicting is true when another Scaler or a HorizontalPodAutoscaler manages the same
+	// target and this Scaler has stopped acting on it
+	ScalerConflicting ScalerConditionType = "Conflicting"
+	// ScalerRolloutInProgress is true while the target is rolling out and scaling is suspended
+	ScalerRolloutInProgress ScalerConditionType = "RolloutInProgress"
+	// ScalerManualOverride is true while a manual change to the replicas of the target is respected
+	ScalerManualOverride ScalerConditionType = "ManualOverride"
+	// ScalerFrozen is true while a blackout window or a ScalingFreeze blocks scaling
+	ScalerFrozen ScalerConditionType = "Frozen"
+)
+
+// ScalerCondition describes the state of a Scaler at a certain point
+// +k8s:deepcopy-gen=true
+type ScalerCondition struct {
+	Type               ScalerConditionType    `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
 }
 
 // ScaleTarget is the scaling target for the Scaler
@@ -52,3 +345,75 @@ type ScalerList struct {
 	metav1.ListMeta `json:"metadata"`
 	Items           []Scaler `json:"items"`
 }
+
+// +genclient
+// +genclient:onlyVerbs=create,delete,deleteCollection,get,list,watch
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ScalingRecord is an audit record of a change the controller made to the replicas of a target.
+// Records are never updated and are deleted when their time to live has passed.
+type ScalingRecord struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ScalingRecordSpec `json:"spec"`
+}
+
+// ScalingRecordSpec describes the change to the replicas of a target
+// +k8s:deepcopy-gen=true
+type ScalingRecordSpec struct {
+	// Scaler is the name of the Scaler which made the change
+	Scaler string      `json:"scaler"`
+	Target ScaleTarget `json:"target"`
+	// Decision holds the replicas before and after, the thresholds and the reason
+	Decision ScalingDecision `json:"decision"`
+	// Metrics are the utilization samples of every pod the decision was based on
+	Metrics []PodMetrics `json:"metrics,omitempty"`
+	// ControllerVersion is the version of the controller which made the change
+	ControllerVersion string `json:"controllerVersion"`
+}
+
+// PodMetrics are the utilization samples of a pod in percent, oldest first
+// +k8s:deepcopy-gen=true
+type PodMetrics struct {
+	Pod     string `json:"pod"`
+	Samples []int  `json:"samples"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// ScalingRecordList is list of ScalingRecords
+type ScalingRecordList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ScalingRecord `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ScalingFreeze blocks scaling of the Scalers in the whole cluster or of the ones it selects,
+// for example during an incident or a release.
+type ScalingFreeze struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ScalingFreezeSpec `json:"spec"`
+}
+
+// ScalingFreezeSpec is the specification for ScalingFreezes
+// +k8s:deepcopy-gen=true
+type ScalingFreezeSpec struct {
+	FreezeWindow `json:",inline"`
+	// Namespaces limits the freeze to the Scalers in these namespaces. All namespaces when empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Selector limits the freeze to the Scalers with matching labels. All Scalers when not set.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// ScalingFreezeList is list of ScalingFreezes
+type ScalingFreezeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ScalingFreeze `json:"items"`
+}
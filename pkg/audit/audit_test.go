@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newRecord(name string, at time.Time) *v1alpha1.ScalingRecord {
+	return &v1alpha1.ScalingRecord{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1alpha1.ScalingRecordSpec{
+			Scaler:   "scaler",
+			Decision: v1alpha1.ScalingDecision{Time: metav1.NewTime(at), FromReplicas: 1, ToReplicas: 2},
+		},
+	}
+}
+
+func readLines(t *testing.T, path string) []v1alpha1.ScalingRecord {
+	file, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer file.Close()
+	var records []v1alpha1.ScalingRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r v1alpha1.ScalingRecord
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+
+	line, _ := json.Marshal(newRecord("record-0", time.Now()))
+	// room for two records per file
+	sink, err := NewFileSink(path, int64(2*(len(line)+1)), 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, name := range []string{"record-0", "record-1", "record-2", "record-3", "record-4", "record-5", "record-6"} {
+		assert.NoError(t, sink.Record(newRecord(name, time.Now())))
+	}
+	assert.NoError(t, sink.Close())
+
+	names := func(records []v1alpha1.ScalingRecord) []string {
+		var result []string
+		for _, r := range records {
+			result = append(result, r.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"record-6"}, names(readLines(t, path)))
+	assert.Equal(t, []string{"record-4", "record-5"}, names(readLines(t, path+".1")))
+	assert.Equal(t, []string{"record-2", "record-3"}, names(readLines(t, path+".2")))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRecordSinkCollect(t *testing.T) {
+	now := time.Date(2019, 1, 2, 12, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset(
+		newRecord("expired", now.Add(-48*time.Hour)),
+		newRecord("recent", now.Add(-time.Hour)),
+	)
+	sink := NewRecordSink(client, "", 24*time.Hour)
+	sink.now = func() time.Time { return now }
+
+	assert.NoError(t, sink.Record(newRecord("new", now)))
+	sink.collect()
+
+	list, err := client.ArjunnaikV1alpha1().ScalingRecords("default").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	var names []string
+	for _, r := range list.Items {
+		names = append(names, r.Name)
+	}
+	assert.Len(t, names, 2)
+	assert.Contains(t, names, "recent")
+	assert.Contains(t, names, "new")
+}
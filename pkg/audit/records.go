@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	clientset "github.com/arjunrn/simple-scaler/pkg/client/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"time"
+)
+
+// RecordPermissions are the permissions the RecordSink needs in the namespace of the controller
+var RecordPermissions = []authorizationv1.ResourceAttributes{
+	{Group: scaler.GroupName, Resource: "scalingrecords", Verb: "create"},
+	{Group: scaler.GroupName, Resource: "scalingrecords", Verb: "list"},
+	{Group: scaler.GroupName, Resource: "scalingrecords", Verb: "delete"},
+}
+
+// RecordSink creates a ScalingRecord object in the namespace of the Scaler for every record.
+// Records are never updated. Records older than the time to live are deleted periodically.
+type RecordSink struct {
+	client clientset.Interface
+	// namespace the records are collected in. Empty for all namespaces.
+	namespace string
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// NewRecordSink creates a sink which keeps the records for the ttl
+func NewRecordSink(client clientset.Interface, namespace string, ttl time.Duration) *RecordSink {
+	return &RecordSink{client: client, namespace: namespace, ttl: ttl, now: time.Now}
+}
+
+// Record creates the ScalingRecord
+func (s *RecordSink) Record(record *v1alpha1.ScalingRecord) error {
+	_, err := s.client.ArjunnaikV1alpha1().ScalingRecords(record.Namespace).Create(record)
+	return err
+}
+
+// Run deletes the expired records until stopCh is closed
+func (s *RecordSink) Run(interval time.Duration, stopCh <-chan struct{}) {
+	if s.ttl <= 0 {
+		return
+	}
+	log.Infof("collecting ScalingRecords older than %s", s.ttl)
+	wait.Until(s.collect, interval, stopCh)
+}
+
+// collect deletes the records whose time to live has passed
+func (s *RecordSink) collect() {
+	records := s.client.ArjunnaikV1alpha1().ScalingRecords(s.namespace)
+	list, err := records.List(metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("failed to list ScalingRecords: %v", err)
+		return
+	}
+	deadline := s.now().Add(-s.ttl)
+	for _, r := range list.Items {
+		if !r.Spec.Decision.Time.Time.Before(deadline) {
+			continue
+		}
+		err := s.client.ArjunnaikV1alpha1().ScalingRecords(r.Namespace).Delete(r.Name, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			log.Errorf("failed to delete ScalingRecord %s/%s: %v", r.Namespace, r.Name, err)
+			continue
+		}
+		log.Debugf("deleted expired ScalingRecord %s/%s", r.Namespace, r.Name)
+	}
+}
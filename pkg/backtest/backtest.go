@@ -0,0 +1,165 @@
+package backtest
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"time"
+)
+
+const (
+	// Step is the interval between two evaluations of the replay, the same as the resolution of
+	// the samples the controller queries
+	Step = time.Minute
+	// DefaultCooldown is the minimum time between two scaling actions of the controller
+	DefaultCooldown = time.Minute
+)
+
+// Event is a change of the replicas during the replay
+type Event struct {
+	Time         time.Time
+	FromReplicas int32
+	ToReplicas   int32
+	// Utilization is the average utilization of the pods the decision was based on
+	Utilization int32
+}
+
+// Result is the outcome of a replay
+type Result struct {
+	Events     []Event
+	ScaleUps   int
+	ScaleDowns int
+	// Blocked counts the evaluations whose proposal was outside of the bounds
+	Blocked int
+	// AtMax and AtMin are how long the target spent at its bounds
+	AtMax time.Duration
+	AtMin time.Duration
+	// Overloaded is how long the pods were used above their request
+	Overloaded   time.Duration
+	PeakReplicas int32
+	// PodHours are the hours of all the replicas added up
+	PodHours float64
+	Duration time.Duration
+}
+
+// Replay runs a Scaler against the recorded demand of its target. The demand is the CPU usage
+// of all the pods in percent of the request of a single pod, one value per Step starting at
+// start. The load is conserved while the replicas change: at every step the demand is spread
+// evenly across the replicas of the simulation. New replicas only take part in the decisions
+// once they have been measured for spec.evaluations steps, as with real pods.
+func Replay(spec v1alpha1.ScalerSpec, demand []float64, start time.Time, replicas int32, cooldown time.Duration) (*Result, error) {
+	if spec.Evaluations < 1 {
+		return nil, fmt.Errorf("evaluations must be at least 1")
+	}
+	if replicas < 1 {
+		return nil, fmt.Errorf("the initial replicas must be at least 1")
+	}
+
+	sim := &simulation{keep: int(spec.Evaluations)}
+	sim.resize(replicas)
+	calculator := replicacalculator.NewReplicaCalculator(sim, sim)
+	result := &Result{PeakReplicas: replicas, Duration: time.Duration(len(demand)) * Step}
+	var lastScaling time.Time
+
+	for i, load := range demand {
+		now := start.Add(time.Duration(i) * Step)
+		current := int32(len(sim.pods))
+		utilization := load / float64(current)
+		sim.record(int(utilization))
+
+		result.PodHours += float64(current) * Step.Hours()
+		if current >= spec.MaxReplicas {
+			result.AtMax += Step
+		}
+		if current <= spec.MinReplicas {
+			result.AtMin += Step
+		}
+		if utilization > 100 {
+			result.Overloaded += Step
+		}
+
+		recommendation, err := calculator.GetRecommendation("", spec.Evaluations, current, spec.ScaleDown,
+			spec.ScaleUp, spec.ScaleUpSize, spec.ScaleDownSize, labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		desired := recommendation.Replicas
+		if desired == current {
+			continue
+		}
+		if desired < spec.MinReplicas || desired > spec.MaxReplicas || desired < 1 {
+			result.Blocked++
+			continue
+		}
+		if !lastScaling.IsZero() && lastScaling.Add(cooldown).After(now) {
+			continue
+		}
+
+		result.Events = append(result.Events, Event{Time: now, FromReplicas: current, ToReplicas: desired,
+			Utilization: recommendation.Utilization.Average})
+		if desired > current {
+			result.ScaleUps++
+		} else {
+			result.ScaleDowns++
+		}
+		if desired > result.PeakReplicas {
+			result.PeakReplicas = desired
+		}
+		lastScaling = now
+		sim.resize(desired)
+	}
+	return result, nil
+}
+
+// simulation is the pod lister and the metrics source of a replay
+type simulation struct {
+	pods    []*corev1.Pod
+	samples map[string][]int
+	created int
+	// keep is the number of samples kept for every pod
+	keep int
+}
+
+// resize adds new pods or removes the newest ones
+func (s *simulation) resize(replicas int32) {
+	if s.samples == nil {
+		s.samples = map[string][]int{}
+	}
+	for int32(len(s.pods)) < replicas {
+		s.created++
+		name := fmt.Sprintf("pod-%d", s.created)
+		s.pods = append(s.pods, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	for int32(len(s.pods)) > replicas {
+		removed := s.pods[len(s.pods)-1]
+		delete(s.samples, removed.Name)
+		s.pods = s.pods[:len(s.pods)-1]
+	}
+}
+
+// record adds a sample to every pod
+func (s *simulation) record(utilization int) {
+	for _, p := range s.pods {
+		samples := append(s.samples[p.Name], utilization)
+		if len(samples) > s.keep {
+			samples = samples[len(samples)-s.keep:]
+		}
+		s.samples[p.Name] = samples
+	}
+}
+
+func (s *simulation) List(namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	return s.pods, nil
+}
+
+// GetPodMetrics returns the samples of every pod. They are never more than the evaluations.
+func (s *simulation) GetPodMetrics(namespace string, podIDs []string, evaluations int32) (map[string][]int, error) {
+	metrics := make(map[string][]int, len(podIDs))
+	for _, id := range podIDs {
+		metrics[id] = s.samples[id]
+	}
+	return metrics, nil
+}
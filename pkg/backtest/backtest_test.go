@@ -0,0 +1,52 @@
+package backtest
+
+import (
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	spec := v1alpha1.ScalerSpec{
+		MinReplicas:   2,
+		MaxReplicas:   4,
+		ScaleUp:       80,
+		ScaleDown:     30,
+		Evaluations:   2,
+		ScaleUpSize:   1,
+		ScaleDownSize: 1,
+	}
+	// ten quiet minutes, an hour of a load which needs more than the maximum and another quiet hour
+	var demand []float64
+	for i := 0; i < 10; i++ {
+		demand = append(demand, 100)
+	}
+	for i := 0; i < 60; i++ {
+		demand = append(demand, 500)
+	}
+	for i := 0; i < 60; i++ {
+		demand = append(demand, 40)
+	}
+	start := time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	result, err := Replay(spec, demand, start, 2, DefaultCooldown)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, 2, result.ScaleUps)
+	assert.Equal(t, 2, result.ScaleDowns)
+	assert.Equal(t, int32(4), result.PeakReplicas)
+	assert.True(t, result.AtMax > 50*time.Minute, "expected most of the busy hour at the maximum but got %s", result.AtMax)
+	assert.True(t, result.Blocked > 0)
+	assert.Equal(t, 130*time.Minute, result.Duration)
+	require.Len(t, result.Events, 4)
+	first := result.Events[0]
+	assert.Equal(t, int32(2), first.FromReplicas)
+	assert.Equal(t, int32(3), first.ToReplicas)
+	// the load rises at 10:10 and has to be measured twice before the first scale up
+	assert.True(t, first.Time.Equal(start.Add(11*time.Minute)), "unexpected time of the first event %s", first.Time)
+
+	_, err = Replay(v1alpha1.ScalerSpec{}, demand, start, 2, DefaultCooldown)
+	assert.Error(t, err)
+}
@@ -32,6 +32,14 @@ func (c *FakeArjunnaikV1alpha1) Scalers(namespace string) v1alpha1.ScalerInterfa
 	return &FakeScalers{c, namespace}
 }
 
+func (c *FakeArjunnaikV1alpha1) ScalingFreezes() v1alpha1.ScalingFreezeInterface {
+	return &FakeScalingFreezes{c}
+}
+
+func (c *FakeArjunnaikV1alpha1) ScalingRecords(namespace string) v1alpha1.ScalingRecordInterface {
+	return &FakeScalingRecords{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeArjunnaikV1alpha1) RESTClient() rest.Interface {
@@ -19,3 +19,7 @@ limitations under the License.
 package v1alpha1
 
 type ScalerExpansion interface{}
+
+type ScalingFreezeExpansion interface{}
+
+type ScalingRecordExpansion interface{}
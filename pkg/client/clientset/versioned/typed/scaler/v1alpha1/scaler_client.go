@@ -28,6 +28,8 @@ import (
 type ArjunnaikV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	ScalersGetter
+	ScalingFreezesGetter
+	ScalingRecordsGetter
 }
 
 // ArjunnaikV1alpha1Client is used to interact with features provided by the arjunnaik.in group.
@@ -39,6 +41,14 @@ func (c *ArjunnaikV1alpha1Client) Scalers(namespace string) ScalerInterface {
 	return newScalers(c, namespace)
 }
 
+func (c *ArjunnaikV1alpha1Client) ScalingFreezes() ScalingFreezeInterface {
+	return newScalingFreezes(c)
+}
+
+func (c *ArjunnaikV1alpha1Client) ScalingRecords(namespace string) ScalingRecordInterface {
+	return newScalingRecords(c, namespace)
+}
+
 // NewForConfig creates a new ArjunnaikV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*ArjunnaikV1alpha1Client, error) {
 	config := *c
@@ -55,6 +55,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=arjunnaik.in, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("scalers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Arjunnaik().V1alpha1().Scalers().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("scalingfreezes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Arjunnaik().V1alpha1().ScalingFreezes().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("scalingrecords"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Arjunnaik().V1alpha1().ScalingRecords().Informer()}, nil
 
 	}
 
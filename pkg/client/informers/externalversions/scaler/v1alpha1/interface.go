@@ -26,6 +26,10 @@ import (
 type Interface interface {
 	// Scalers returns a ScalerInformer.
 	Scalers() ScalerInformer
+	// ScalingFreezes returns a ScalingFreezeInformer.
+	ScalingFreezes() ScalingFreezeInformer
+	// ScalingRecords returns a ScalingRecordInformer.
+	ScalingRecords() ScalingRecordInformer
 }
 
 type version struct {
@@ -43,3 +47,13 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) Scalers() ScalerInformer {
 	return &scalerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ScalingFreezes returns a ScalingFreezeInformer.
+func (v *version) ScalingFreezes() ScalingFreezeInformer {
+	return &scalingFreezeInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ScalingRecords returns a ScalingRecordInformer.
+func (v *version) ScalingRecords() ScalingRecordInformer {
+	return &scalingRecordInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -25,3 +25,15 @@ type ScalerListerExpansion interface{}
 // ScalerNamespaceListerExpansion allows custom methods to be added to
 // ScalerNamespaceLister.
 type ScalerNamespaceListerExpansion interface{}
+
+// ScalingFreezeListerExpansion allows custom methods to be added to
+// ScalingFreezeLister.
+type ScalingFreezeListerExpansion interface{}
+
+// ScalingRecordListerExpansion allows custom methods to be added to
+// ScalingRecordLister.
+type ScalingRecordListerExpansion interface{}
+
+// ScalingRecordNamespaceListerExpansion allows custom methods to be added to
+// ScalingRecordNamespaceLister.
+type ScalingRecordNamespaceListerExpansion interface{}
@@ -0,0 +1,161 @@
+package cloudevents
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+const (
+	// SpecVersion is the version of the CloudEvents specification the events follow
+	SpecVersion = "1.0"
+
+	structuredContentType = "application/cloudevents+json"
+	dataContentType       = "application/json"
+)
+
+// Mode is the HTTP content mode of the events
+type Mode string
+
+const (
+	// Binary puts the attributes in ce- headers and the data in the body
+	Binary Mode = "binary"
+	// Structured puts the whole event in a JSON body
+	Structured Mode = "structured"
+)
+
+// Event is a CloudEvent with a JSON data payload
+type Event struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// Publisher sends events to an HTTP sink in the background. Events are buffered and dropped
+// when the buffer is full so that a slow sink never blocks the caller.
+type Publisher struct {
+	client *http.Client
+	url    string
+	source string
+	mode   Mode
+	events chan Event
+}
+
+// NewPublisher creates a publisher which buffers up to bufferSize events
+func NewPublisher(client *http.Client, url, source string, mode Mode, bufferSize int) (*Publisher, error) {
+	if mode != Binary && mode != Structured {
+		return nil, fmt.Errorf("unknown CloudEvents mode %q", mode)
+	}
+	return &Publisher{
+		client: client,
+		url:    url,
+		source: source,
+		mode:   mode,
+		events: make(chan Event, bufferSize),
+	}, nil
+}
+
+// Publish queues an event of the type with the data. It returns false if the buffer was full
+// and the event was dropped.
+func (p *Publisher) Publish(eventType, subject string, data interface{}) bool {
+	event := Event{
+		SpecVersion:     SpecVersion,
+		ID:              newID(),
+		Source:          p.source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: dataContentType,
+		Data:            data,
+	}
+	select {
+	case p.events <- event:
+		return true
+	default:
+		log.Warnf("CloudEvents buffer is full. dropping %s event for %s", eventType, subject)
+		return false
+	}
+}
+
+// Run sends the buffered events until stopCh is closed
+func (p *Publisher) Run(stopCh <-chan struct{}) {
+	for {
+		select {
+		case event := <-p.events:
+			if err := p.send(event); err != nil {
+				log.Errorf("failed to send %s event for %s: %v", event.Type, event.Subject, err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (p *Publisher) send(event Event) error {
+	req, err := p.request(event)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return nil
+}
+
+// request encodes the event in the content mode of the publisher
+func (p *Publisher) request(event Event) (*http.Request, error) {
+	if p.mode == Structured {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", structuredContentType)
+		return req, nil
+	}
+
+	body, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", event.DataContentType)
+	req.Header.Set("ce-specversion", event.SpecVersion)
+	req.Header.Set("ce-id", event.ID)
+	req.Header.Set("ce-source", event.Source)
+	req.Header.Set("ce-type", event.Type)
+	req.Header.Set("ce-time", event.Time.Format(time.RFC3339Nano))
+	if event.Subject != "" {
+		req.Header.Set("ce-subject", event.Subject)
+	}
+	return req, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
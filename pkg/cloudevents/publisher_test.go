@@ -0,0 +1,72 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestRequest(t *testing.T) {
+	data := map[string]int{"toReplicas": 3}
+
+	testCases := []struct {
+		name        string
+		mode        Mode
+		contentType string
+		headers     map[string]string
+	}{
+		{
+			name:        "binary",
+			mode:        Binary,
+			contentType: "application/json",
+			headers: map[string]string{
+				"ce-specversion": "1.0",
+				"ce-source":      "simple-scaler",
+				"ce-type":        "scaler.scaled",
+				"ce-subject":     "default/web",
+			},
+		},
+		{
+			name:        "structured",
+			mode:        Structured,
+			contentType: "application/cloudevents+json",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := NewPublisher(http.DefaultClient, "http://sink", "simple-scaler", c.mode, 1)
+			assert.NoError(t, err)
+			assert.True(t, p.Publish("scaler.scaled", "default/web", data))
+			assert.False(t, p.Publish("scaler.scaled", "default/web", data), "buffer should be full")
+
+			event := <-p.events
+			req, err := p.request(event)
+			assert.NoError(t, err)
+			assert.Equal(t, c.contentType, req.Header.Get("Content-Type"))
+			for k, v := range c.headers {
+				assert.Equal(t, v, req.Header.Get(k), k)
+			}
+			assert.NotEmpty(t, event.ID)
+
+			body, _ := ioutil.ReadAll(req.Body)
+			if c.mode == Binary {
+				assert.Equal(t, `{"toReplicas":3}`, string(body))
+				return
+			}
+			var decoded map[string]interface{}
+			assert.NoError(t, json.Unmarshal(body, &decoded))
+			assert.Equal(t, "1.0", decoded["specversion"])
+			assert.Equal(t, "scaler.scaled", decoded["type"])
+			assert.Equal(t, "default/web", decoded["subject"])
+			assert.Equal(t, map[string]interface{}{"toReplicas": float64(3)}, decoded["data"])
+		})
+	}
+}
+
+func TestUnknownMode(t *testing.T) {
+	_, err := NewPublisher(http.DefaultClient, "http://sink", "simple-scaler", Mode("text"), 1)
+	assert.Error(t, err)
+}
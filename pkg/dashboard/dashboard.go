@@ -0,0 +1,268 @@
+// Package dashboard serves a read-only HTML page with the state of all the Scalers
+package dashboard
+
+import (
+	"fmt"
+	"github.com/arjunrn/simple-scaler/controller"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"html/template"
+	corev1 "k8s.io/api/core/v1"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// recentDecisions is the number of decisions from the history shown for every Scaler
+	recentDecisions = 3
+
+	sparklineWidth  = 120
+	sparklineHeight = 24
+)
+
+// Source provides the Scalers and their latest evaluations
+type Source interface {
+	Scalers() ([]*v1alpha1.Scaler, error)
+	LastEvaluation(namespace, name string) (controller.Evaluation, bool)
+}
+
+// NewHandler returns the handler of the dashboard
+func NewHandler(source Source) http.Handler {
+	return &handler{source: source, now: time.Now}
+}
+
+type handler struct {
+	source Source
+	now    func() time.Time
+}
+
+// row is a Scaler as shown on the dashboard
+type row struct {
+	Namespace   string
+	Name        string
+	Target      string
+	Current     int32
+	Desired     int32
+	Min         int32
+	Max         int32
+	State       string
+	Utilization string
+	Reason      string
+	Evaluated   string
+	Sparkline   *sparkline
+	Decisions   []decisionRow
+}
+
+type decisionRow struct {
+	Age     string
+	From    int32
+	To      int32
+	Result  string
+	Reason  string
+	Blocked bool
+}
+
+// sparkline is the utilization of the metric window drawn as an SVG polyline. The thresholds
+// are drawn as horizontal lines.
+type sparkline struct {
+	Width     int
+	Height    int
+	Points    string
+	ScaleUp   int
+	ScaleDown int
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	scalers, err := h.source.Scalers()
+	if err != nil {
+		log.Errorf("failed to list the Scalers for the dashboard: %v", err)
+		http.Error(w, "failed to list the Scalers", http.StatusInternalServerError)
+		return
+	}
+	now := h.now()
+	rows := make([]row, len(scalers))
+	for i, s := range scalers {
+		evaluation, ok := h.source.LastEvaluation(s.Namespace, s.Name)
+		rows[i] = newRow(s, evaluation, ok, now)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, struct {
+		Rows []row
+		Time string
+	}{rows, now.UTC().Format(time.RFC3339)}); err != nil {
+		log.Errorf("failed to render the dashboard: %v", err)
+	}
+}
+
+func newRow(s *v1alpha1.Scaler, e controller.Evaluation, evaluated bool, now time.Time) row {
+	r := row{
+		Namespace:   s.Namespace,
+		Name:        s.Name,
+		Target:      fmt.Sprintf("%s/%s", s.Spec.Target.Kind, s.Spec.Target.Name),
+		Current:     s.Status.CurrentReplicas,
+		Desired:     s.Status.DesiredReplicas,
+		Min:         s.Spec.MinReplicas,
+		Max:         s.Spec.MaxReplicas,
+		State:       state(s),
+		Utilization: "-",
+		Reason:      s.Status.Reason,
+		Evaluated:   "never",
+	}
+	if evaluated {
+		r.Current = e.CurrentReplicas
+		r.Desired = e.DesiredReplicas
+		r.Min = e.MinReplicas
+		r.Max = e.MaxReplicas
+		r.Reason = e.Reason
+		r.Evaluated = age(now, e.Time) + " ago"
+		if e.Utilization.Pods > 0 {
+			r.Utilization = fmt.Sprintf("%d%% (%d-%d%%)", e.Utilization.Average, e.Utilization.Min, e.Utilization.Max)
+		}
+		r.Sparkline = newSparkline(e.Window, e.ScaleUpThreshold, e.ScaleDownThreshold)
+	}
+
+	history := s.Status.History
+	if len(history) > recentDecisions {
+		history = history[len(history)-recentDecisions:]
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		d := history[i]
+		result := "applied"
+		switch {
+		case d.Blocked:
+			result = "blocked"
+		case d.DryRun:
+			result = "recommended"
+		}
+		r.Decisions = append(r.Decisions, decisionRow{Age: age(now, d.Time.Time), From: d.FromReplicas,
+			To: d.ToReplicas, Result: result, Reason: d.Reason, Blocked: d.Blocked})
+	}
+	return r
+}
+
+// newSparkline scales the window to the height of the sparkline. The scale reaches at least to
+// the scale up threshold so that the lines of the thresholds are always visible.
+func newSparkline(window []int32, scaleUp, scaleDown int32) *sparkline {
+	if len(window) == 0 {
+		return nil
+	}
+	top := scaleUp + 10
+	for _, v := range window {
+		if v > top {
+			top = v
+		}
+	}
+	y := func(v int32) int {
+		return sparklineHeight - int(v)*sparklineHeight/int(top)
+	}
+	points := make([]string, len(window))
+	for i, v := range window {
+		x := 0
+		if len(window) > 1 {
+			x = i * sparklineWidth / (len(window) - 1)
+		}
+		points[i] = fmt.Sprintf("%d,%d", x, y(v))
+	}
+	return &sparkline{
+		Width:     sparklineWidth,
+		Height:    sparklineHeight,
+		Points:    strings.Join(points, " "),
+		ScaleUp:   y(scaleUp),
+		ScaleDown: y(scaleDown),
+	}
+}
+
+// state lists the overrides and the true conditions of the Scaler
+func state(s *v1alpha1.Scaler) string {
+	var states []string
+	for _, o := range s.Status.Overrides {
+		states = append(states, string(o.Type))
+	}
+	for _, c := range s.Status.Conditions {
+		if c.Status == corev1.ConditionTrue {
+			states = append(states, string(c.Type))
+		}
+	}
+	if s.Spec.DryRun {
+		states = append(states, "DryRun")
+	}
+	if len(states) == 0 {
+		return "Active"
+	}
+	return strings.Join(states, ", ")
+}
+
+func age(now, t time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+var page = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>Scalers</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #ddd; vertical-align: top; }
+th { background: #f4f4f4; }
+.number { text-align: right; }
+.muted { color: #777; }
+.blocked { color: #b00; }
+ul { margin: 0; padding-left: 1em; }
+svg polyline { fill: none; stroke: #2a6ebb; stroke-width: 1.5; }
+svg line.up { stroke: #b00; stroke-dasharray: 2,2; }
+svg line.down { stroke: #080; stroke-dasharray: 2,2; }
+</style>
+</head>
+<body>
+<h1>Scalers</h1>
+<p class="muted">{{len .Rows}} Scalers at {{.Time}}. The page reloads every 30 seconds.</p>
+<table>
+<tr>
+<th>Scaler</th><th>Target</th><th class="number">Current</th><th class="number">Desired</th>
+<th class="number">Min</th><th class="number">Max</th><th>Utilization</th><th>Window</th><th>State</th>
+<th>Last evaluation</th><th>Recent decisions</th>
+</tr>
+{{range .Rows}}
+<tr>
+<td>{{.Namespace}}/{{.Name}}</td>
+<td>{{.Target}}</td>
+<td class="number">{{.Current}}</td>
+<td class="number">{{.Desired}}</td>
+<td class="number">{{.Min}}</td>
+<td class="number">{{.Max}}</td>
+<td>{{.Utilization}}</td>
+<td>{{with .Sparkline}}<svg width="{{.Width}}" height="{{.Height}}">
+<line class="up" x1="0" x2="{{.Width}}" y1="{{.ScaleUp}}" y2="{{.ScaleUp}}"/>
+<line class="down" x1="0" x2="{{.Width}}" y1="{{.ScaleDown}}" y2="{{.ScaleDown}}"/>
+<polyline points="{{.Points}}"/>
+</svg>{{else}}<span class="muted">-</span>{{end}}</td>
+<td>{{.State}}</td>
+<td>{{.Evaluated}}<br><span class="muted">{{.Reason}}</span></td>
+<td>{{if .Decisions}}<ul>{{range .Decisions}}
+<li{{if .Blocked}} class="blocked"{{end}}>{{.Age}} ago: {{.From}} &rarr; {{.To}} {{.Result}}<br><span class="muted">{{.Reason}}</span></li>
+{{end}}</ul>{{else}}<span class="muted">none</span>{{end}}</td>
+</tr>
+{{else}}
+<tr><td colspan="11" class="muted">No Scalers</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
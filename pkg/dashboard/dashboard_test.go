@@ -0,0 +1,82 @@
+package dashboard
+
+import (
+	"github.com/arjunrn/simple-scaler/controller"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	scalers     []*v1alpha1.Scaler
+	evaluations map[string]controller.Evaluation
+}
+
+func (f *fakeSource) Scalers() ([]*v1alpha1.Scaler, error) {
+	return f.scalers, nil
+}
+
+func (f *fakeSource) LastEvaluation(namespace, name string) (controller.Evaluation, bool) {
+	e, ok := f.evaluations[namespace+"/"+name]
+	return e, ok
+}
+
+func TestDashboard(t *testing.T) {
+	now := time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC)
+	source := &fakeSource{
+		scalers: []*v1alpha1.Scaler{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+				Spec: v1alpha1.ScalerSpec{MinReplicas: 1, MaxReplicas: 10,
+					Target: v1alpha1.ScaleTarget{Kind: "Deployment", Name: "web"}},
+				Status: v1alpha1.ScalerStatus{History: []v1alpha1.ScalingDecision{
+					{Time: metav1.NewTime(now.Add(-10 * time.Minute)), FromReplicas: 2, ToReplicas: 3, Reason: "busy"},
+					{Time: metav1.NewTime(now.Add(-2 * time.Minute)), FromReplicas: 3, ToReplicas: 11, Blocked: true,
+						Reason: "above the <maximum>"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "worker"},
+				Spec:       v1alpha1.ScalerSpec{DryRun: true, Target: v1alpha1.ScaleTarget{Kind: "StatefulSet", Name: "worker"}},
+			},
+		},
+		evaluations: map[string]controller.Evaluation{
+			"default/web": {Time: now.Add(-20 * time.Second), CurrentReplicas: 3, DesiredReplicas: 4, MinReplicas: 2,
+				MaxReplicas: 10, ScaleUpThreshold: 70, ScaleDownThreshold: 20, Reason: "utilization above 70%",
+				Utilization: replicacalculator.Utilization{Average: 80, Min: 75, Max: 85, Pods: 3},
+				Window:      []int32{60, 75, 80, 85, 90}},
+		},
+	}
+	h := &handler{source: source, now: func() time.Time { return now }}
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, "default/web")
+	assert.Contains(t, body, "Deployment/web")
+	assert.Contains(t, body, "80% (75-85%)")
+	assert.Contains(t, body, "20s ago")
+	assert.Contains(t, body, `<polyline points="0,8 30,4 60,3 90,2 120,0"/>`)
+	assert.Contains(t, body, "2m ago: 3 &rarr; 11 blocked")
+	assert.Contains(t, body, "above the &lt;maximum&gt;")
+	assert.Contains(t, body, "batch/worker")
+	assert.Contains(t, body, "DryRun")
+
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestSparkline(t *testing.T) {
+	s := newSparkline([]int32{0, 40, 80}, 70, 20)
+	assert.Equal(t, "0,24 60,12 120,0", s.Points)
+	assert.Equal(t, 3, s.ScaleUp)
+	assert.Equal(t, 18, s.ScaleDown)
+	assert.Nil(t, newSparkline(nil, 70, 20))
+}
@@ -0,0 +1,207 @@
+// Package externalmetrics serves the utilization and the desired replicas of the Scalers as
+// external metrics, so that a HorizontalPodAutoscaler can target them. It implements the
+// external.metrics.k8s.io API behind an APIService of the API aggregation layer.
+package externalmetrics
+
+import (
+	"encoding/json"
+	"github.com/arjunrn/simple-scaler/controller"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// GroupName is the API group of the external metrics
+	GroupName = "external.metrics.k8s.io"
+	// Version is the version of the API which is served
+	Version = "v1beta1"
+
+	// UtilizationMetric is the average utilization of the pods of the target over the metric
+	// window, in percent of the request
+	UtilizationMetric = "scaler_utilization"
+	// ReplicaRatioMetric is the ratio of the desired to the current replicas. An HPA which
+	// targets a value of 1 scales the target like the Scaler would.
+	ReplicaRatioMetric = "scaler_replica_ratio"
+
+	// ScalerLabel and TargetLabel are the labels of the metrics which select a Scaler
+	ScalerLabel = "scaler"
+	TargetLabel = "target"
+)
+
+var groupVersion = schema.GroupVersion{Group: GroupName, Version: Version}
+
+// ExternalMetricValueList is the list of external metric values. It matches the type of the
+// same name in k8s.io/metrics.
+type ExternalMetricValueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExternalMetricValue `json:"items"`
+}
+
+// ExternalMetricValue is a metric value for an external metric
+type ExternalMetricValue struct {
+	metav1.TypeMeta `json:",inline"`
+	MetricName      string            `json:"metricName"`
+	MetricLabels    map[string]string `json:"metricLabels"`
+	Timestamp       metav1.Time       `json:"timestamp"`
+	// WindowSeconds is the window the value was computed over when it is not a point in time
+	WindowSeconds *int64            `json:"window,omitempty"`
+	Value         resource.Quantity `json:"value"`
+}
+
+// Source provides the Scalers and their latest evaluations
+type Source interface {
+	Scalers() ([]*v1alpha1.Scaler, error)
+	LastEvaluation(namespace, name string) (controller.Evaluation, bool)
+}
+
+// NewHandler returns the handler of the external metrics API. Evaluations which are older than
+// maxAge are not served, so that an HPA does not act on a Scaler which is no longer evaluated.
+func NewHandler(source Source, maxAge time.Duration) http.Handler {
+	return &handler{source: source, maxAge: maxAge, now: time.Now}
+}
+
+type handler struct {
+	source Source
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatus(w, errors.NewMethodNotSupported(schema.GroupResource{Group: GroupName}, r.Method))
+		return
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	prefix := "apis/" + GroupName
+	switch {
+	case path == "healthz":
+		w.Write([]byte("ok"))
+	case path == prefix:
+		h.writeGroup(w)
+	case path == prefix+"/"+Version:
+		h.writeResources(w)
+	case strings.HasPrefix(path, prefix+"/"+Version+"/namespaces/"):
+		parts := strings.Split(strings.TrimPrefix(path, prefix+"/"+Version+"/namespaces/"), "/")
+		if len(parts) != 2 {
+			writeStatus(w, errors.NewNotFound(groupVersion.WithResource("").GroupResource(), path))
+			return
+		}
+		selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+		if err != nil {
+			writeStatus(w, errors.NewBadRequest(err.Error()))
+			return
+		}
+		h.writeMetric(w, parts[0], parts[1], selector)
+	default:
+		writeStatus(w, errors.NewNotFound(groupVersion.WithResource("").GroupResource(), path))
+	}
+}
+
+func (h *handler) writeGroup(w http.ResponseWriter) {
+	version := metav1.GroupVersionForDiscovery{GroupVersion: groupVersion.String(), Version: Version}
+	writeJSON(w, http.StatusOK, &metav1.APIGroup{
+		TypeMeta:         metav1.TypeMeta{Kind: "APIGroup", APIVersion: "v1"},
+		Name:             GroupName,
+		Versions:         []metav1.GroupVersionForDiscovery{version},
+		PreferredVersion: version,
+	})
+}
+
+func (h *handler) writeResources(w http.ResponseWriter) {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: groupVersion.String(),
+	}
+	for _, name := range []string{UtilizationMetric, ReplicaRatioMetric} {
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       name,
+			Namespaced: true,
+			Kind:       "ExternalMetricValueList",
+			Verbs:      metav1.Verbs{"get"},
+		})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *handler) writeMetric(w http.ResponseWriter, namespace, metric string, selector labels.Selector) {
+	if metric != UtilizationMetric && metric != ReplicaRatioMetric {
+		writeStatus(w, errors.NewNotFound(groupVersion.WithResource(metric).GroupResource(), metric))
+		return
+	}
+	scalers, err := h.source.Scalers()
+	if err != nil {
+		log.Errorf("failed to list the Scalers for the external metrics: %v", err)
+		writeStatus(w, errors.NewInternalError(err))
+		return
+	}
+	list := &ExternalMetricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "ExternalMetricValueList", APIVersion: groupVersion.String()},
+		Items:    []ExternalMetricValue{},
+	}
+	now := h.now()
+	for _, s := range scalers {
+		if s.Namespace != namespace {
+			continue
+		}
+		metricLabels := map[string]string{ScalerLabel: s.Name, TargetLabel: s.Spec.Target.Name}
+		if !selector.Matches(labels.Set(metricLabels)) {
+			continue
+		}
+		e, ok := h.source.LastEvaluation(s.Namespace, s.Name)
+		if !ok || now.Sub(e.Time) > h.maxAge {
+			continue
+		}
+		value, ok := metricValue(metric, e)
+		if !ok {
+			continue
+		}
+		list.Items = append(list.Items, ExternalMetricValue{
+			MetricName:   metric,
+			MetricLabels: metricLabels,
+			Timestamp:    metav1.NewTime(e.Time),
+			Value:        value,
+		})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// metricValue returns the value of the metric for the evaluation. There is no value when the
+// evaluation had no pods to measure.
+func metricValue(metric string, e controller.Evaluation) (resource.Quantity, bool) {
+	switch metric {
+	case UtilizationMetric:
+		if e.Utilization.Pods == 0 {
+			return resource.Quantity{}, false
+		}
+		return *resource.NewQuantity(int64(e.Utilization.Average), resource.DecimalSI), true
+	default:
+		if e.CurrentReplicas == 0 {
+			return resource.Quantity{}, false
+		}
+		return *resource.NewMilliQuantity(int64(e.DesiredReplicas)*1000/int64(e.CurrentReplicas), resource.DecimalSI), true
+	}
+}
+
+// writeStatus writes the error as a Status like the API server does
+func writeStatus(w http.ResponseWriter, err *errors.StatusError) {
+	status := err.ErrStatus
+	status.TypeMeta = metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}
+	writeJSON(w, int(status.Code), &status)
+}
+
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Errorf("failed to write the external metrics response: %v", err)
+	}
+}
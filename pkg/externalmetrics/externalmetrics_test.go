@@ -0,0 +1,150 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"github.com/arjunrn/simple-scaler/controller"
+	"github.com/arjunrn/simple-scaler/pkg/apis/scaler/v1alpha1"
+	"github.com/arjunrn/simple-scaler/pkg/replicacalculator"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	scalers     []*v1alpha1.Scaler
+	evaluations map[string]controller.Evaluation
+}
+
+func (f *fakeSource) Scalers() ([]*v1alpha1.Scaler, error) {
+	return f.scalers, nil
+}
+
+func (f *fakeSource) LastEvaluation(namespace, name string) (controller.Evaluation, bool) {
+	e, ok := f.evaluations[namespace+"/"+name]
+	return e, ok
+}
+
+func newScaler(namespace, name, target string) *v1alpha1.Scaler {
+	return &v1alpha1.Scaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       v1alpha1.ScalerSpec{Target: v1alpha1.ScaleTarget{Kind: "Deployment", Name: target}},
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	now := time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC)
+	source := &fakeSource{
+		scalers: []*v1alpha1.Scaler{
+			newScaler("default", "web", "web"),
+			newScaler("default", "api", "api-server"),
+			newScaler("default", "stale", "stale"),
+			newScaler("default", "idle", "idle"),
+			newScaler("default", "never", "never"),
+			newScaler("batch", "worker", "worker"),
+		},
+		evaluations: map[string]controller.Evaluation{
+			"default/web": {Time: now.Add(-10 * time.Second), CurrentReplicas: 4, DesiredReplicas: 6,
+				Utilization: replicacalculator.Utilization{Average: 85, Pods: 4}},
+			"default/api": {Time: now.Add(-20 * time.Second), CurrentReplicas: 3, DesiredReplicas: 2,
+				Utilization: replicacalculator.Utilization{Average: 15, Pods: 3}},
+			"default/stale": {Time: now.Add(-5 * time.Minute), CurrentReplicas: 2, DesiredReplicas: 2,
+				Utilization: replicacalculator.Utilization{Average: 50, Pods: 2}},
+			"default/idle": {Time: now, CurrentReplicas: 0, DesiredReplicas: 0},
+			"batch/worker": {Time: now, CurrentReplicas: 1, DesiredReplicas: 1,
+				Utilization: replicacalculator.Utilization{Average: 40, Pods: 1}},
+		},
+	}
+	h := &handler{source: source, maxAge: 90 * time.Second, now: func() time.Time { return now }}
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected map[string]string
+	}{
+		{
+			name:     "utilization of all the Scalers in the namespace",
+			path:     "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/scaler_utilization",
+			expected: map[string]string{"web": "85", "api": "15"},
+		},
+		{
+			name:     "utilization of one Scaler",
+			path:     "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/scaler_utilization?labelSelector=scaler%3Dweb",
+			expected: map[string]string{"web": "85"},
+		},
+		{
+			name:     "selected by the target",
+			path:     "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/scaler_replica_ratio?labelSelector=target%3Dapi-server",
+			expected: map[string]string{"api": "666m"},
+		},
+		{
+			name:     "replica ratio",
+			path:     "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/scaler_replica_ratio",
+			expected: map[string]string{"web": "1500m", "api": "666m"},
+		},
+		{
+			name:     "other namespace",
+			path:     "/apis/external.metrics.k8s.io/v1beta1/namespaces/batch/scaler_utilization",
+			expected: map[string]string{"worker": "40"},
+		},
+		{
+			name:     "nothing selected",
+			path:     "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/scaler_utilization?labelSelector=scaler%3Ddb",
+			expected: map[string]string{},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, c.path, nil))
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var list ExternalMetricValueList
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+			assert.Equal(t, "ExternalMetricValueList", list.Kind)
+			values := map[string]string{}
+			for _, item := range list.Items {
+				values[item.MetricLabels[ScalerLabel]] = item.Value.String()
+			}
+			assert.Equal(t, c.expected, values)
+		})
+	}
+}
+
+func TestDiscoveryAndErrors(t *testing.T) {
+	h := NewHandler(&fakeSource{}, time.Minute)
+
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		code     int
+		contains string
+	}{
+		{name: "group", method: http.MethodGet, path: "/apis/external.metrics.k8s.io", code: http.StatusOK,
+			contains: `"preferredVersion":{"groupVersion":"external.metrics.k8s.io/v1beta1"`},
+		{name: "resources", method: http.MethodGet, path: "/apis/external.metrics.k8s.io/v1beta1", code: http.StatusOK,
+			contains: `"name":"scaler_replica_ratio","singularName":"","namespaced":true,"kind":"ExternalMetricValueList"`},
+		{name: "health", method: http.MethodGet, path: "/healthz", code: http.StatusOK, contains: "ok"},
+		{name: "unknown metric", method: http.MethodGet,
+			path: "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/cpu", code: http.StatusNotFound,
+			contains: `"kind":"Status"`},
+		{name: "invalid selector", method: http.MethodGet,
+			path: "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/scaler_utilization?labelSelector=%3D%3D",
+			code: http.StatusBadRequest, contains: `"reason":"BadRequest"`},
+		{name: "write", method: http.MethodPost,
+			path: "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/scaler_utilization",
+			code: http.StatusMethodNotAllowed, contains: `"reason":"MethodNotAllowed"`},
+		{name: "unknown path", method: http.MethodGet, path: "/apis/metrics.k8s.io/v1beta1", code: http.StatusNotFound,
+			contains: `"reason":"NotFound"`},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(c.method, c.path, nil))
+			assert.Equal(t, c.code, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), c.contains)
+		})
+	}
+}
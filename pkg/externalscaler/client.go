@@ -0,0 +1,69 @@
+// Package externalscaler calls external scalers, which provide the metrics of a Scaler over
+// gRPC. The protocol is the one of externalscaler.proto. The calls are made without TLS like
+// the ones of KEDA to its external scalers.
+package externalscaler
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative externalscaler.proto
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"time"
+)
+
+// Client calls the external scaler at an address
+type Client struct {
+	address string
+	timeout time.Duration
+	conn    *grpc.ClientConn
+	client  ExternalScalerClient
+}
+
+// NewClient returns a client for the external scaler at the address, which is host:port. The
+// connection is made on the first call, and every call has to complete within the timeout.
+func NewClient(address string, timeout time.Duration) (*Client, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{address: address, timeout: timeout, conn: conn, client: NewExternalScalerClient(conn)}, nil
+}
+
+// Close closes the connection to the external scaler
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// IsActive asks whether the workload of the Scaler is in use
+func (c *Client) IsActive(ref *ScaledObjectRef) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	response, err := c.client.IsActive(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	return response.Result, nil
+}
+
+// GetMetricSpec returns the metrics of the Scaler with the value a single replica can handle
+func (c *Client) GetMetricSpec(ref *ScaledObjectRef) ([]*MetricSpec, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	response, err := c.client.GetMetricSpec(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return response.MetricSpecs, nil
+}
+
+// GetMetrics returns the current values of the metric
+func (c *Client) GetMetrics(ref *ScaledObjectRef, metricName string) ([]*MetricValue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	response, err := c.client.GetMetrics(ctx, &GetMetricsRequest{ScaledObjectRef: ref, MetricName: metricName})
+	if err != nil {
+		return nil, err
+	}
+	return response.MetricValues, nil
+}
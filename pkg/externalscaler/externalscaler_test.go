@@ -0,0 +1,182 @@
+package externalscaler
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+// stub is an external scaler with fixed metrics
+type stub struct {
+	UnimplementedExternalScalerServer
+	active  bool
+	specs   []*MetricSpec
+	values  map[string]int64
+	err     error
+	lastRef *ScaledObjectRef
+}
+
+func (s *stub) IsActive(ctx context.Context, ref *ScaledObjectRef) (*IsActiveResponse, error) {
+	s.lastRef = ref
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &IsActiveResponse{Result: s.active}, nil
+}
+
+func (s *stub) GetMetricSpec(ctx context.Context, ref *ScaledObjectRef) (*GetMetricSpecResponse, error) {
+	s.lastRef = ref
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &GetMetricSpecResponse{MetricSpecs: s.specs}, nil
+}
+
+func (s *stub) GetMetrics(ctx context.Context, request *GetMetricsRequest) (*GetMetricsResponse, error) {
+	s.lastRef = request.ScaledObjectRef
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &GetMetricsResponse{MetricValues: []*MetricValue{
+		{MetricName: request.MetricName, MetricValue: s.values[request.MetricName]},
+	}}, nil
+}
+
+// newStub serves the stub with grpc-go on a local port. Without a stub the server has no
+// services.
+func newStub(t *testing.T, s *stub) (*Client, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	if s != nil {
+		RegisterExternalScalerServer(server, s)
+	}
+	go server.Serve(listener)
+	client, err := NewClient(listener.Addr().String(), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, func() {
+		client.Close()
+		server.Stop()
+	}
+}
+
+func TestClient(t *testing.T) {
+	s := &stub{
+		active: true,
+		specs:  []*MetricSpec{{MetricName: "queue", TargetSize: 10}},
+		values: map[string]int64{"queue": 42},
+	}
+	client, stop := newStub(t, s)
+	defer stop()
+	ref := &ScaledObjectRef{Name: "worker", Namespace: "batch", ScalerMetadata: map[string]string{"queue": "jobs"}}
+
+	active, err := client.IsActive(ref)
+	assert.NoError(t, err)
+	assert.True(t, active)
+	assert.Equal(t, ref.ScalerMetadata, s.lastRef.ScalerMetadata)
+
+	specs, err := client.GetMetricSpec(ref)
+	assert.NoError(t, err)
+	assert.Len(t, specs, 1)
+	assert.Equal(t, int64(10), specs[0].TargetSize)
+
+	values, err := client.GetMetrics(ref, "queue")
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, int64(42), values[0].MetricValue)
+	assert.Equal(t, "worker", s.lastRef.Name)
+
+	s.err = status.Error(codes.InvalidArgument, "unknown queue: jobs")
+	_, err = client.GetMetricSpec(ref)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "unknown queue: jobs", status.Convert(err).Message())
+
+	s.err = fmt.Errorf("broker unavailable")
+	_, err = client.IsActive(ref)
+	assert.Equal(t, codes.Unknown, status.Code(err))
+	assert.Equal(t, "broker unavailable", status.Convert(err).Message())
+
+	other, stopOther := newStub(t, nil)
+	defer stopOther()
+	_, err = other.IsActive(ref)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	unreachable, err := NewClient("127.0.0.1:1", 5*time.Second)
+	assert.NoError(t, err)
+	defer unreachable.Close()
+	_, err = unreachable.IsActive(ref)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestMetricsSource(t *testing.T) {
+	s := &stub{
+		active: false,
+		specs:  []*MetricSpec{{MetricName: "queue", TargetSize: 10}, {MetricName: "lag", TargetSize: 100}},
+		values: map[string]int64{"queue": 30, "lag": 150},
+	}
+	client, stop := newStub(t, s)
+	defer stop()
+	source := NewMetricsSource(client, &ScaledObjectRef{Name: "worker", Namespace: "batch"})
+	now := time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC)
+	source.now = func() time.Time { return now }
+
+	// 30 of 10 per pod on 2 pods is 150%, 150 of 100 per pod is 75%
+	metrics, err := source.GetPodMetrics("batch", []string{"worker-1", "worker-2"}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]int{"worker-1": {150}, "worker-2": {150}}, metrics)
+
+	// a sample in the same minute replaces the previous one
+	s.values["queue"] = 20
+	now = now.Add(30 * time.Second)
+	metrics, err = source.GetPodMetrics("batch", []string{"worker-1", "worker-2"}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{100}, metrics["worker-1"])
+
+	for _, queue := range []int64{10, 60, 90} {
+		s.values["queue"] = queue
+		now = now.Add(time.Minute)
+		metrics, err = source.GetPodMetrics("batch", []string{"worker-1", "worker-2"}, 3)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []int{75, 300, 450}, metrics["worker-2"])
+
+	// the minutes without an evaluation keep the previous value
+	s.values["queue"] = 40
+	now = now.Add(3 * time.Minute)
+	metrics, err = source.GetPodMetrics("batch", []string{"worker-1", "worker-2"}, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{450, 450, 450, 200}, metrics["worker-1"])
+
+	// after a longer gap the window starts over
+	now = now.Add(2 * time.Hour)
+	metrics, err = source.GetPodMetrics("batch", []string{"worker-1", "worker-2"}, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{200}, metrics["worker-1"])
+
+	metrics, err = source.GetPodMetrics("batch", nil, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, metrics)
+
+	value, err := source.GetActivationValue("jobs > 0")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), value)
+	assert.Equal(t, "jobs > 0", s.lastRef.ScalerMetadata[ActivationQueryKey])
+	s.active = true
+	value, err = source.GetActivationValue("jobs > 0")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), value)
+
+	s.specs = []*MetricSpec{{MetricName: "queue"}}
+	_, err = source.GetPodMetrics("batch", []string{"worker-1"}, 3)
+	assert.Error(t, err)
+}
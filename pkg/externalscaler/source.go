@@ -0,0 +1,138 @@
+package externalscaler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// ActivationQueryKey is the key of the metadata which carries the activation query of a
+	// Scaler which scales to zero in IsActive
+	ActivationQueryKey = "activationQuery"
+
+	// sampleInterval is the step of the window, like the step of the Prometheus queries. The
+	// window has a sample for every minute however often the Scaler is evaluated.
+	sampleInterval = time.Minute
+	// maxSamples limits the samples which are kept
+	maxSamples = 60
+)
+
+// MetricsSource provides the utilization of the pods of a Scaler from its external scaler. The
+// external scaler only knows the current values of its metrics, so the samples of the window are
+// collected by the evaluations, one for every minute.
+type MetricsSource struct {
+	client *Client
+	ref    *ScaledObjectRef
+	now    func() time.Time
+
+	mu      sync.Mutex
+	samples []int
+	sampled time.Time
+}
+
+// NewMetricsSource returns the metrics source of the Scaler
+func NewMetricsSource(client *Client, ref *ScaledObjectRef) *MetricsSource {
+	return &MetricsSource{client: client, ref: ref, now: time.Now}
+}
+
+// Close closes the connections to the external scaler
+func (s *MetricsSource) Close() {
+	s.client.Close()
+}
+
+// GetPodMetrics returns the samples of the utilization for every pod. The utilization is the
+// total value of a metric in percent of the target size of all the pods. With several metrics
+// the highest utilization counts, like it does for an HPA. Every pod has the same samples.
+func (s *MetricsSource) GetPodMetrics(namespace string, podIDs []string, evaluations int32) (map[string][]int, error) {
+	metrics := make(map[string][]int, len(podIDs))
+	if len(podIDs) == 0 {
+		return metrics, nil
+	}
+	utilization, err := s.utilization(len(podIDs))
+	if err != nil {
+		return nil, err
+	}
+	window := s.record(utilization, int(evaluations))
+	for _, p := range podIDs {
+		metrics[p] = window
+	}
+	return metrics, nil
+}
+
+// GetActivationValue asks the external scaler whether the workload of the Scaler is in use. The
+// query is passed in the metadata. The value is 1 when it is in use and 0 otherwise.
+func (s *MetricsSource) GetActivationValue(query string) (float64, error) {
+	ref := &ScaledObjectRef{Name: s.ref.Name, Namespace: s.ref.Namespace, ScalerMetadata: map[string]string{}}
+	for k, v := range s.ref.ScalerMetadata {
+		ref.ScalerMetadata[k] = v
+	}
+	ref.ScalerMetadata[ActivationQueryKey] = query
+	active, err := s.client.IsActive(ref)
+	if err != nil {
+		return 0, err
+	}
+	if active {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (s *MetricsSource) utilization(pods int) (int, error) {
+	specs, err := s.client.GetMetricSpec(s.ref)
+	if err != nil {
+		return 0, err
+	}
+	if len(specs) == 0 {
+		return 0, fmt.Errorf("the external scaler %s has no metrics", s.client.address)
+	}
+	highest := 0
+	for _, spec := range specs {
+		if spec.TargetSize <= 0 {
+			return 0, fmt.Errorf("the metric %s has the target size %d", spec.MetricName, spec.TargetSize)
+		}
+		values, err := s.client.GetMetrics(s.ref, spec.MetricName)
+		if err != nil {
+			return 0, err
+		}
+		var total int64
+		for _, v := range values {
+			total += v.MetricValue
+		}
+		if u := int(total * 100 / (spec.TargetSize * int64(pods))); u > highest {
+			highest = u
+		}
+	}
+	return highest, nil
+}
+
+// record adds the sample for the current minute to the window and returns the latest samples.
+// A later sample in the same minute replaces the previous one. Minutes without an evaluation
+// keep the previous value, and after a longer gap the window starts over.
+func (s *MetricsSource) record(utilization, evaluations int) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	minute := s.now().Truncate(sampleInterval)
+	switch {
+	case len(s.samples) > 0 && !minute.After(s.sampled):
+		s.samples[len(s.samples)-1] = utilization
+	case len(s.samples) > 0 && minute.Sub(s.sampled) <= maxSamples*sampleInterval:
+		last := s.samples[len(s.samples)-1]
+		for m := s.sampled.Add(sampleInterval); m.Before(minute); m = m.Add(sampleInterval) {
+			s.samples = append(s.samples, last)
+		}
+		s.samples = append(s.samples, utilization)
+		s.sampled = minute
+	default:
+		s.samples = []int{utilization}
+		s.sampled = minute
+	}
+	if len(s.samples) > maxSamples {
+		s.samples = s.samples[len(s.samples)-maxSamples:]
+	}
+	start := len(s.samples) - evaluations
+	if start < 0 {
+		start = 0
+	}
+	return append([]int(nil), s.samples[start:]...)
+}
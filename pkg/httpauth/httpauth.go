@@ -0,0 +1,257 @@
+// Package httpauth protects the HTTP endpoints of the controller with a bearer token, either a
+// static one or a Kubernetes token which is checked with a TokenReview. The permissions of the
+// users of Kubernetes tokens are checked with SubjectAccessReviews.
+package httpauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// reviewCacheTTL is how long the outcome of a review is reused for the same token
+	reviewCacheTTL = time.Minute
+)
+
+// ErrUnauthorized is returned when the request carries no valid token
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrForbidden is returned when the token is valid but the user may not access the endpoint
+var ErrForbidden = errors.New("forbidden")
+
+// User is the user a request was authenticated as
+type User struct {
+	Name   string
+	UID    string
+	Groups []string
+	Extra  map[string]authorizationv1.ExtraValue
+}
+
+// Authenticator checks the credentials of a request and returns its user
+type Authenticator interface {
+	Authenticate(token string) (User, error)
+}
+
+// Authorizer checks whether a user may perform an action. It returns ErrForbidden when the user
+// may not.
+type Authorizer interface {
+	Authorize(user User, permission authorizationv1.ResourceAttributes) error
+}
+
+// NewStaticAuthenticator accepts a single token
+func NewStaticAuthenticator(token string) Authenticator {
+	return &staticAuthenticator{token: token}
+}
+
+type staticAuthenticator struct {
+	token string
+}
+
+func (a *staticAuthenticator) Authenticate(token string) (User, error) {
+	if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		return User{}, ErrUnauthorized
+	}
+	return User{Name: "token"}, nil
+}
+
+// AllowAll returns an Authorizer which allows every user everything. It goes with a static
+// token whose holder is trusted with all the endpoints.
+func AllowAll() Authorizer {
+	return allowAll{}
+}
+
+type allowAll struct{}
+
+func (allowAll) Authorize(User, authorizationv1.ResourceAttributes) error {
+	return nil
+}
+
+// NewTokenReviewAuthenticator accepts Kubernetes tokens. The token is checked with a TokenReview
+// on the API server and the outcome is cached for a minute.
+func NewTokenReviewAuthenticator(client kubernetes.Interface) Authenticator {
+	return &tokenReviewAuthenticator{client: client, cache: map[[sha256.Size]byte]review{}}
+}
+
+type tokenReviewAuthenticator struct {
+	client kubernetes.Interface
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]review
+}
+
+type review struct {
+	user    User
+	err     error
+	expires time.Time
+}
+
+func (a *tokenReviewAuthenticator) Authenticate(token string) (User, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+	a.mu.Lock()
+	cached, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.user, cached.err
+	}
+
+	user, err := a.review(token)
+	if err != nil && err != ErrUnauthorized {
+		// failures to reach the API server are not cached
+		return User{}, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	expireReviews(a.cache, now)
+	a.cache[key] = review{user: user, err: err, expires: now.Add(reviewCacheTTL)}
+	return user, err
+}
+
+func (a *tokenReviewAuthenticator) review(token string) (User, error) {
+	tokenReview, err := a.client.AuthenticationV1().TokenReviews().Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return User{}, fmt.Errorf("failed to review the token: %v", err)
+	}
+	if !tokenReview.Status.Authenticated {
+		return User{}, ErrUnauthorized
+	}
+	info := tokenReview.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(info.Extra))
+	for k, v := range info.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	return User{Name: info.Username, UID: info.UID, Groups: info.Groups, Extra: extra}, nil
+}
+
+// NewSubjectAccessReviewAuthorizer checks the permissions of the users of Kubernetes tokens with
+// a SubjectAccessReview on the API server. The outcome is cached for a minute.
+func NewSubjectAccessReviewAuthorizer(client kubernetes.Interface) Authorizer {
+	return &accessReviewAuthorizer{client: client, cache: map[[sha256.Size]byte]review{}}
+}
+
+type accessReviewAuthorizer struct {
+	client kubernetes.Interface
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]review
+}
+
+func (a *accessReviewAuthorizer) Authorize(user User, permission authorizationv1.ResourceAttributes) error {
+	// the extra values are printed in the order of their keys
+	key := sha256.Sum256([]byte(fmt.Sprintf("%#v %#v", user, permission)))
+	now := time.Now()
+	a.mu.Lock()
+	cached, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.err
+	}
+
+	accessReview, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(&authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &permission,
+			User:               user.Name,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              user.Extra,
+		},
+	})
+	if err != nil {
+		// failures to reach the API server are not cached
+		return fmt.Errorf("failed to review the access of %s: %v", user.Name, err)
+	}
+	if !accessReview.Status.Allowed {
+		err = ErrForbidden
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	expireReviews(a.cache, now)
+	a.cache[key] = review{user: user, err: err, expires: now.Add(reviewCacheTTL)}
+	return err
+}
+
+func expireReviews(cache map[[sha256.Size]byte]review, now time.Time) {
+	for k, r := range cache {
+		if now.After(r.expires) {
+			delete(cache, k)
+		}
+	}
+}
+
+type userKey struct{}
+
+// UserFrom returns the user a request passed on by Handler was authenticated as
+func UserFrom(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey{}).(User)
+	return user, ok
+}
+
+// Handler only passes on the requests which the authenticator accepts, with the user in the
+// context. The token is read from the Authorization header either as a bearer token or as the
+// password of basic authentication, so that a browser can prompt for it.
+func Handler(a Authenticator, realm string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", realm))
+			http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+			return
+		}
+		user, err := a.Authenticate(token)
+		switch err {
+		case nil:
+		case ErrUnauthorized:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", realm))
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		default:
+			log.Errorf("failed to authenticate a request: %v", err)
+			http.Error(w, "authentication failed", http.StatusInternalServerError)
+			return
+		}
+		log.Debugf("%s %s by %s", r.Method, r.URL.Path, user.Name)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+// Require only passes on the requests of users who have the permission. It has to be wrapped by
+// Handler.
+func Require(a Authorizer, permission authorizationv1.ResourceAttributes, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := UserFrom(r.Context())
+		switch err := a.Authorize(user, permission); err {
+		case nil:
+			next.ServeHTTP(w, r)
+		case ErrForbidden:
+			log.Infof("denied %s %s to %s", r.Method, r.URL.Path, user.Name)
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			log.Errorf("failed to authorize a request: %v", err)
+			http.Error(w, "authorization failed", http.StatusInternalServerError)
+		}
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package httpauth
+
+import (
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	reviews, accessReviews := 0, 0
+	client.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "admin-token":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true,
+				User: authenticationv1.UserInfo{Username: "admin"}}
+		case "viewer-token":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true,
+				User: authenticationv1.UserInfo{Username: "viewer"}}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		accessReviews++
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "admin" && review.Spec.ResourceAttributes.Resource == "scalers"
+		return true, review, nil
+	})
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	permission := authorizationv1.ResourceAttributes{Group: "arjunnaik.in", Resource: "scalers", Verb: "list"}
+	static := NewStaticAuthenticator("secret")
+	testCases := []struct {
+		name          string
+		authenticator Authenticator
+		authorizer    Authorizer
+		bearer        string
+		password      string
+		expected      int
+	}{
+		{name: "static token", authenticator: static, authorizer: AllowAll(), bearer: "secret", expected: http.StatusOK},
+		{name: "static token as password", authenticator: static, authorizer: AllowAll(), password: "secret",
+			expected: http.StatusOK},
+		{name: "wrong static token", authenticator: static, authorizer: AllowAll(), bearer: "guess",
+			expected: http.StatusUnauthorized},
+		{name: "no token", authenticator: static, authorizer: AllowAll(), expected: http.StatusUnauthorized},
+		{name: "allowed user", authenticator: NewTokenReviewAuthenticator(client),
+			authorizer: NewSubjectAccessReviewAuthorizer(client), bearer: "admin-token", expected: http.StatusOK},
+		{name: "user without permission", authenticator: NewTokenReviewAuthenticator(client),
+			authorizer: NewSubjectAccessReviewAuthorizer(client), bearer: "viewer-token", expected: http.StatusForbidden},
+		{name: "invalid token", authenticator: NewTokenReviewAuthenticator(client),
+			authorizer: NewSubjectAccessReviewAuthorizer(client), bearer: "expired", expected: http.StatusUnauthorized},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.bearer != "" {
+				request.Header.Set("Authorization", "Bearer "+c.bearer)
+			}
+			if c.password != "" {
+				request.SetBasicAuth("", c.password)
+			}
+			recorder := httptest.NewRecorder()
+			Handler(c.authenticator, "test", Require(c.authorizer, permission, ok)).ServeHTTP(recorder, request)
+			assert.Equal(t, c.expected, recorder.Code)
+		})
+	}
+
+	t.Run("the user is passed on", func(t *testing.T) {
+		var user User
+		handler := Handler(NewTokenReviewAuthenticator(client), "test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _ = UserFrom(r.Context())
+		}))
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Authorization", "Bearer viewer-token")
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		assert.Equal(t, "viewer", user.Name)
+	})
+
+	t.Run("reviews are cached", func(t *testing.T) {
+		authenticator := NewTokenReviewAuthenticator(client)
+		authorizer := NewSubjectAccessReviewAuthorizer(client)
+		before, beforeAccess := reviews, accessReviews
+		for i := 0; i < 3; i++ {
+			user, err := authenticator.Authenticate("admin-token")
+			assert.NoError(t, err)
+			assert.Equal(t, "admin", user.Name)
+			assert.NoError(t, authorizer.Authorize(user, permission))
+		}
+		assert.Equal(t, before+1, reviews)
+		assert.Equal(t, beforeAccess+1, accessReviews)
+
+		other := permission
+		other.Namespace = "team-a"
+		user, _ := authenticator.Authenticate("admin-token")
+		assert.NoError(t, authorizer.Authorize(user, other))
+		assert.Equal(t, beforeAccess+2, accessReviews, "every permission is reviewed")
+	})
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+const (
+	// SignatureHeader holds the hex encoded HMAC-SHA256 of the body, prefixed with "sha256="
+	SignatureHeader = "X-Scaler-Signature"
+	// EventHeader holds the type of the event
+	EventHeader = "X-Scaler-Event"
+)
+
+// Event is what is sent to the notification endpoints
+type Event struct {
+	Type         string    `json:"type"`
+	Namespace    string    `json:"namespace"`
+	Scaler       string    `json:"scaler"`
+	Target       string    `json:"target"`
+	FromReplicas int32     `json:"fromReplicas"`
+	ToReplicas   int32     `json:"toReplicas"`
+	Reason       string    `json:"reason"`
+	Failures     int       `json:"failures,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// Message is an event rendered for one endpoint
+type Message struct {
+	URL   string
+	Event Event
+	Body  []byte
+	// Key signs the body when it is set
+	Key []byte
+}
+
+// Render builds the message body from the template. The event is marshalled to JSON when the
+// template is empty. The strings of the event are escaped for a JSON string before they are
+// passed to the template, so a reason with quotes or new lines cannot break the body.
+func Render(tmpl string, event Event) ([]byte, error) {
+	if tmpl == "" {
+		return json.Marshal(event)
+	}
+	t, err := template.New("notification").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	if err := t.Execute(&body, escapeStrings(event)); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// escapeStrings returns the event with its strings escaped to be placed inside a JSON string
+func escapeStrings(event Event) Event {
+	for _, s := range []*string{&event.Type, &event.Namespace, &event.Scaler, &event.Target, &event.Reason} {
+		quoted, _ := json.Marshal(*s)
+		*s = string(quoted[1 : len(quoted)-1])
+	}
+	return event
+}
+
+// Sign returns the value of the signature header for the body
+func Sign(key, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notifier delivers messages in the background. A message which cannot be delivered is retried
+// with an exponential backoff and dropped after the last attempt.
+type Notifier struct {
+	client   *http.Client
+	messages chan Message
+	attempts int
+	backoff  time.Duration
+}
+
+// NewNotifier creates a notifier which queues up to queueSize messages
+func NewNotifier(client *http.Client, queueSize, attempts int, backoff time.Duration) *Notifier {
+	return &Notifier{
+		client:   client,
+		messages: make(chan Message, queueSize),
+		attempts: attempts,
+		backoff:  backoff,
+	}
+}
+
+// Notify queues the message for delivery. It returns false when the queue is full and the
+// message was dropped.
+func (n *Notifier) Notify(m Message) bool {
+	select {
+	case n.messages <- m:
+		return true
+	default:
+		log.Warnf("notification queue is full. dropping %s notification to %s", m.Event.Type, m.URL)
+		return false
+	}
+}
+
+// Run delivers the queued messages with the given number of workers until stopCh is closed
+func (n *Notifier) Run(workers int, stopCh <-chan struct{}) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case m := <-n.messages:
+					n.deliver(m, stopCh)
+				case <-stopCh:
+					return
+				}
+			}
+		}()
+	}
+	<-stopCh
+}
+
+// deliver sends the message until it is accepted, the attempts are used up or stopCh is closed
+func (n *Notifier) deliver(m Message, stopCh <-chan struct{}) {
+	backoff := n.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.send(m)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= n.attempts {
+			log.Errorf("failed to deliver %s notification for %s/%s to %s after %d attempts: %v", m.Event.Type,
+				m.Event.Namespace, m.Event.Scaler, m.URL, attempt, err)
+			return
+		}
+		log.Debugf("retrying %s notification to %s in %s: %v", m.Event.Type, m.URL, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-stopCh:
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// send posts the message once. It returns true if a failed request should be retried.
+func (n *Notifier) send(m Message) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, m.URL, bytes.NewReader(m.Body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, m.Event.Type)
+	if len(m.Key) > 0 {
+		req.Header.Set(SignatureHeader, Sign(m.Key, m.Body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected response %s", resp.Status)
+	// client errors other than throttling will not go away by retrying
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, err
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	event := Event{Type: "ScaledUp", Namespace: "default", Scaler: "web", FromReplicas: 2, ToReplicas: 4,
+		Time: time.Date(2019, 1, 2, 12, 0, 0, 0, time.UTC)}
+
+	testCases := []struct {
+		name     string
+		template string
+		reason   string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "default body",
+			expected: `{"type":"ScaledUp","namespace":"default","scaler":"web","target":"","fromReplicas":2,"toReplicas":4,"reason":"","time":"2019-01-02T12:00:00Z"}`,
+		},
+		{
+			name:     "template",
+			template: `{"text": "{{.Scaler}} scaled from {{.FromReplicas}} to {{.ToReplicas}}"}`,
+			expected: `{"text": "web scaled from 2 to 4"}`,
+		},
+		{
+			name:     "escaped strings",
+			template: `{"text": "{{.Reason}}"}`,
+			reason:   "the \"web\" pods are\nbusy",
+			expected: `{"text": "the \"web\" pods are\nbusy"}`,
+		},
+		{
+			name:     "invalid template",
+			template: `{{.Scaler`,
+			err:      true,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			e := event
+			e.Reason = c.reason
+			body, err := Render(c.template, e)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, string(body))
+		})
+	}
+}
+
+func TestNotifierDelivery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		statuses []int
+		attempts int32
+	}{
+		{name: "accepted", statuses: []int{http.StatusOK}, attempts: 1},
+		{name: "retried after server error", statuses: []int{http.StatusBadGateway, http.StatusOK}, attempts: 2},
+		{name: "gives up after the last attempt", statuses: []int{500, 500, 500, 500}, attempts: 3},
+		{name: "client error is not retried", statuses: []int{http.StatusBadRequest, http.StatusOK}, attempts: 1},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			var calls int32
+			var signature, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := atomic.AddInt32(&calls, 1)
+				signature = r.Header.Get(SignatureHeader)
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+				w.WriteHeader(c.statuses[i-1])
+			}))
+			defer server.Close()
+
+			n := NewNotifier(server.Client(), 1, 3, time.Millisecond)
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			n.deliver(Message{URL: server.URL, Event: Event{Type: "ScaledUp"}, Body: []byte(`{}`), Key: []byte("secret")}, stopCh)
+
+			assert.Equal(t, c.attempts, atomic.LoadInt32(&calls))
+			assert.Equal(t, `{}`, body)
+			assert.Equal(t, Sign([]byte("secret"), []byte(`{}`)), signature)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package predict
+
+import (
+	"fmt"
+	"math"
+)
+
+// smoothingGrid are the values tried for the smoothing factors when a model is fitted
+var smoothingGrid = []float64{0.1, 0.3, 0.5, 0.7, 0.9}
+
+// trendGrid are the values tried for the trend smoothing factor. The trend is kept smooth since
+// a few noisy samples should not extrapolate into a large change.
+var trendGrid = []float64{0.01, 0.05, 0.2}
+
+// Model is an additive Holt-Winters model, i.e. a series is described by a level, a linear trend
+// and a seasonal component which repeats every Period samples
+type Model struct {
+	Alpha  float64
+	Beta   float64
+	Gamma  float64
+	Period int
+	// RMSE is the root mean square error of the one step ahead forecasts over the fitted series
+	RMSE float64
+
+	level     float64
+	trend     float64
+	seasonals []float64
+	mean      float64
+}
+
+// Fit returns the model with the smoothing factors which forecast the series best one step
+// ahead. The series needs to cover at least two periods.
+func Fit(series []float64, period int) (*Model, error) {
+	var best *Model
+	for _, alpha := range smoothingGrid {
+		for _, beta := range trendGrid {
+			for _, gamma := range smoothingGrid {
+				m, err := FitWith(series, period, alpha, beta, gamma)
+				if err != nil {
+					return nil, err
+				}
+				if best == nil || m.RMSE < best.RMSE {
+					best = m
+				}
+			}
+		}
+	}
+	return best, nil
+}
+
+// FitWith fits a model with the given smoothing factors for the level, the trend and the season
+func FitWith(series []float64, period int, alpha, beta, gamma float64) (*Model, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("the period must be positive")
+	}
+	if len(series) < 2*period {
+		return nil, fmt.Errorf("%d samples are not enough to fit a model with a period of %d", len(series), period)
+	}
+
+	m := &Model{Alpha: alpha, Beta: beta, Gamma: gamma, Period: period}
+	first, second := average(series[:period]), average(series[period:2*period])
+	m.level = first
+	m.trend = (second - first) / float64(period)
+	seasonals := make([]float64, len(series))
+	for i := 0; i < period; i++ {
+		seasonals[i] = series[i] - first
+	}
+
+	var squares float64
+	for t := period; t < len(series); t++ {
+		predicted := m.level + m.trend + seasonals[t-period]
+		squares += (series[t] - predicted) * (series[t] - predicted)
+
+		level := alpha*(series[t]-seasonals[t-period]) + (1-alpha)*(m.level+m.trend)
+		m.trend = beta*(level-m.level) + (1-beta)*m.trend
+		m.level = level
+		seasonals[t] = gamma*(series[t]-level) + (1-gamma)*seasonals[t-period]
+	}
+	m.RMSE = math.Sqrt(squares / float64(len(series)-period))
+	m.seasonals = seasonals[len(series)-period:]
+	m.mean = average(series)
+	return m, nil
+}
+
+// Forecast returns the forecasts for the next steps after the end of the fitted series
+func (m *Model) Forecast(steps int) []float64 {
+	forecast := make([]float64, steps)
+	for h := 1; h <= steps; h++ {
+		forecast[h-1] = m.level + float64(h)*m.trend + m.seasonals[(h-1)%m.Period]
+	}
+	return forecast
+}
+
+// Confidence is a measure between 0 and 1 of how well the model describes the series. It is 1
+// for a perfect fit and drops to 0 when the typical error is as large as the mean of the series.
+func (m *Model) Confidence() float64 {
+	if m.mean <= 0 {
+		if m.RMSE == 0 {
+			return 1
+		}
+		return 0
+	}
+	return math.Max(0, 1-m.RMSE/m.mean)
+}
+
+func average(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
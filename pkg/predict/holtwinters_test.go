@@ -0,0 +1,49 @@
+package predict
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+// seasonal returns a series with a daily like cycle of the given period on top of a linear trend
+func seasonal(samples, period int, trend float64) []float64 {
+	series := make([]float64, samples)
+	for i := range series {
+		series[i] = 200 + trend*float64(i) + 100*math.Sin(2*math.Pi*float64(i)/float64(period))
+	}
+	return series
+}
+
+func TestFitForecastsSeasonalSeries(t *testing.T) {
+	period := 24
+	series := seasonal(10*period, period, 0.5)
+	expected := seasonal(10*period+6, period, 0.5)[10*period:]
+
+	m, err := Fit(series, period)
+	assert.NoError(t, err)
+	if m == nil {
+		return
+	}
+	forecast := m.Forecast(6)
+	assert.Len(t, forecast, 6)
+	for i := range forecast {
+		assert.InDelta(t, expected[i], forecast[i], 0.05*expected[i])
+	}
+	assert.True(t, m.Confidence() > 0.95, "expected a high confidence but got %f", m.Confidence())
+}
+
+func TestConfidenceOfNoise(t *testing.T) {
+	// a series which alternates without any pattern matching the period cannot be forecast well
+	series := []float64{10, 90, 10, 10, 90, 90, 10, 90, 90, 10, 10, 10, 90, 10, 90, 90}
+	m, err := Fit(series, 4)
+	assert.NoError(t, err)
+	if m != nil {
+		assert.True(t, m.Confidence() < 0.5, "expected a low confidence but got %f", m.Confidence())
+	}
+}
+
+func TestFitNeedsTwoPeriods(t *testing.T) {
+	_, err := Fit(make([]float64, 30), 24)
+	assert.Error(t, err)
+}
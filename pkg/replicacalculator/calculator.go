@@ -3,29 +3,64 @@ package replicacalculator
 import (
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/labels"
-	corelisters "k8s.io/client-go/listers/core/v1"
 )
 
 type ReplicaCalculator struct {
-	podLister         corelisters.PodLister
+	podLister         PodLister
 	prometheusMetrics MetricsSource
 }
 
 // NewReplicaCalculator Creates a  new replica calculator
-func NewReplicaCalculator(lister corelisters.PodLister, prometheusMetrics MetricsSource) *ReplicaCalculator {
+func NewReplicaCalculator(lister PodLister, prometheusMetrics MetricsSource) *ReplicaCalculator {
 	return &ReplicaCalculator{
 		podLister:         lister,
 		prometheusMetrics: prometheusMetrics,
 	}
 }
 
+// Recommendation is the outcome of a replica calculation
+type Recommendation struct {
+	// Replicas is the proposed number of replicas
+	Replicas  int32
+	ScaleUp   bool
+	ScaleDown bool
+	// PodMetrics are the utilization samples of every pod in percent
+	PodMetrics map[string][]int
+	// Utilization summarizes the samples of all the pods
+	Utilization Utilization
+}
+
+// Utilization is the utilization of a set of pods in percent
+type Utilization struct {
+	// Average is the average of all the samples
+	Average int32
+	// Min is the lowest average of a single pod
+	Min int32
+	// Max is the highest average of a single pod
+	Max int32
+	// Pods is the number of pods which had samples
+	Pods int32
+}
+
 // GetResourceReplicas get number of replicas for the deployment
 func (c *ReplicaCalculator) GetResourceReplicas(namespace string, evaluations, currentReplicas,
 downThreshold, upThreshold, scaleUpSize, scaleDownSize int32, selector labels.Selector) (int32, error) {
-	pods, err := c.podLister.Pods(namespace).List(selector)
+	recommendation, err := c.GetRecommendation(namespace, evaluations, currentReplicas, downThreshold, upThreshold,
+		scaleUpSize, scaleDownSize, selector)
 	if err != nil {
 		return -1, err
 	}
+	return recommendation.Replicas, nil
+}
+
+// GetRecommendation computes the number of replicas for the deployment and returns it along
+// with the metrics it was based on
+func (c *ReplicaCalculator) GetRecommendation(namespace string, evaluations, currentReplicas,
+downThreshold, upThreshold, scaleUpSize, scaleDownSize int32, selector labels.Selector) (*Recommendation, error) {
+	pods, err := c.podLister.List(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
 
 	podNames := make([]string, len(pods))
 	for i, p := range pods {
@@ -36,7 +71,7 @@ downThreshold, upThreshold, scaleUpSize, scaleDownSize int32, selector labels.Se
 
 	metrics, err := c.prometheusMetrics.GetPodMetrics(namespace, podNames, evaluations)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
 
 	log.Debugf("pod metrics: %v", metrics)
@@ -56,7 +91,46 @@ downThreshold, upThreshold, scaleUpSize, scaleDownSize int32, selector labels.Se
 		proposedReplicas -= scaleDownSize
 	}
 
-	return proposedReplicas, nil
+	return &Recommendation{
+		Replicas:    proposedReplicas,
+		ScaleUp:     scaleUp,
+		ScaleDown:   scaleDown,
+		PodMetrics:  metrics,
+		Utilization: summarize(podNames, metrics),
+	}, nil
+}
+
+// summarize aggregates the samples of the pods
+func summarize(podNames []string, podMetrics map[string][]int) Utilization {
+	var (
+		u       Utilization
+		total   int
+		samples int
+	)
+	for _, p := range podNames {
+		pMetrics := podMetrics[p]
+		if len(pMetrics) == 0 {
+			continue
+		}
+		podTotal := 0
+		for _, m := range pMetrics {
+			podTotal += m
+		}
+		podAverage := int32(podTotal / len(pMetrics))
+		if u.Pods == 0 || podAverage < u.Min {
+			u.Min = podAverage
+		}
+		if u.Pods == 0 || podAverage > u.Max {
+			u.Max = podAverage
+		}
+		u.Pods++
+		total += podTotal
+		samples += len(pMetrics)
+	}
+	if samples > 0 {
+		u.Average = int32(total / samples)
+	}
+	return u
 }
 
 func (c *ReplicaCalculator) shouldScale(podNames []string, podMetrics map[string][]int, scaleUpThreshold,
@@ -84,3 +84,31 @@ func TestNewReplicaCalculator(t *testing.T) {
 		})
 	}
 }
+
+func TestSummarize(t *testing.T) {
+	testCases := []struct {
+		name       string
+		podMetrics map[string][]int
+		podNames   []string
+		expected   Utilization
+	}{
+		{
+			name:       "no metrics",
+			podMetrics: map[string][]int{"abc": {}},
+			podNames:   []string{"abc"},
+			expected:   Utilization{},
+		},
+		{
+			name:       "pods with and without metrics",
+			podMetrics: map[string][]int{"abc": {10, 20}, "def": {50, 70}, "ghi": {}},
+			podNames:   []string{"abc", "def", "ghi"},
+			expected:   Utilization{Average: 37, Min: 15, Max: 60, Pods: 2},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, summarize(c.podNames, c.podMetrics))
+		})
+	}
+}
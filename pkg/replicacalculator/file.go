@@ -0,0 +1,209 @@
+package replicacalculator
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/timeseries"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// fileLookback is how far back a sample is used for a later point in time, the same as the
+	// staleness period of Prometheus
+	fileLookback = 5 * time.Minute
+)
+
+// FileMetricsSource serves the utilization of pods from files instead of Prometheus, e.g. to
+// drive the controller with scripted load curves. The path is either a JSON file with an
+// object mapping "namespace/pod" to an array of samples, or a directory containing a
+// <namespace>/<pod>.csv or .json file for every pod in the formats read by pkg/timeseries.
+// The values are the utilization in percent.
+type FileMetricsSource struct {
+	path string
+	// replay shifts all the samples so that the earliest one is at the time the source was
+	// created, so that a curve plays from the start of the controller
+	replay bool
+	start  time.Time
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	series  map[string]timeseries.Series
+	shift   time.Duration
+	modTime time.Time
+}
+
+// NewFileMetricsSource reads the metrics at the path
+func NewFileMetricsSource(path string, replay bool) (*FileMetricsSource, error) {
+	s := &FileMetricsSource{path: path, replay: replay, start: time.Now(), now: time.Now}
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetPodMetrics returns one sample per minute for the evaluations up to the current minute.
+// Minutes without a sample in the lookback period before them are left out.
+func (s *FileMetricsSource) GetPodMetrics(namespace string, podIDs []string, evaluations int32) (map[string][]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	end := s.now().Truncate(time.Minute)
+	result := make(map[string][]int)
+	for _, pod := range podIDs {
+		series, ok := s.series[namespace+"/"+pod]
+		if !ok {
+			continue
+		}
+		var values []int
+		for i := evaluations - 1; i >= 0; i-- {
+			if value, ok := valueAt(series, end.Add(-time.Duration(i)*time.Minute).Add(-s.shift)); ok {
+				values = append(values, int(value))
+			}
+		}
+		if len(values) > 0 {
+			result[pod] = values
+		}
+	}
+	return result, nil
+}
+
+// Watch reloads the metrics every interval when the files have changed until the channel is closed
+func (s *FileMetricsSource) Watch(interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			changed, err := s.reload()
+			if err != nil {
+				log.Errorf("failed to reload the metrics from %s: %v", s.path, err)
+			} else if changed {
+				log.Infof("reloaded the metrics from %s", s.path)
+			}
+		}
+	}
+}
+
+// reload reads the files again if any of them changed since the last time
+func (s *FileMetricsSource) reload() (bool, error) {
+	modTime, err := latestModTime(s.path)
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := s.series != nil && !modTime.After(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	series, err := loadPodSeries(s.path)
+	if err != nil {
+		return false, err
+	}
+	var shift time.Duration
+	if s.replay {
+		if earliest := earliestSample(series); !earliest.IsZero() {
+			shift = s.start.Sub(earliest)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.series = series
+	s.shift = shift
+	s.modTime = modTime
+	return true, nil
+}
+
+// loadPodSeries reads the series of every pod keyed by namespace/pod
+func loadPodSeries(path string) (map[string]timeseries.Series, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		series := map[string]timeseries.Series{}
+		if err := json.Unmarshal(data, &series); err != nil {
+			return nil, err
+		}
+		for key, s := range series {
+			if strings.Count(key, "/") != 1 {
+				return nil, fmt.Errorf("expected a key of the form namespace/pod but got %q", key)
+			}
+			s.Sort()
+		}
+		return series, nil
+	}
+
+	series := map[string]timeseries.Series{}
+	files, err := filepath.Glob(filepath.Join(path, "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		ext := filepath.Ext(file)
+		if ext != ".csv" && ext != ".json" {
+			continue
+		}
+		s, err := timeseries.Load(file)
+		if err != nil {
+			return nil, err
+		}
+		namespace := filepath.Base(filepath.Dir(file))
+		pod := strings.TrimSuffix(filepath.Base(file), ext)
+		series[namespace+"/"+pod] = s
+	}
+	return series, nil
+}
+
+// latestModTime returns the latest modification time of the file or of any file in the directory
+func latestModTime(path string) (time.Time, error) {
+	var latest time.Time
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
+
+func earliestSample(series map[string]timeseries.Series) time.Time {
+	var earliest time.Time
+	for _, s := range series {
+		if len(s) > 0 && (earliest.IsZero() || s[0].Time.Before(earliest)) {
+			earliest = s[0].Time
+		}
+	}
+	return earliest
+}
+
+// valueAt returns the latest sample at or before the time within the lookback period
+func valueAt(series timeseries.Series, t time.Time) (float64, bool) {
+	for i := len(series) - 1; i >= 0; i-- {
+		if series[i].Time.After(t) {
+			continue
+		}
+		if t.Sub(series[i].Time) > fileLookback {
+			return 0, false
+		}
+		return series[i].Value, true
+	}
+	return 0, false
+}
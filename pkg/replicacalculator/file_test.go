@@ -0,0 +1,133 @@
+package replicacalculator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestFileMetricsSourceReplicas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the web pods are busy for the last three minutes, the worker pods are idle
+	writeFile(t, filepath.Join(dir, "default", "web-1.csv"), "timestamp,value\n"+
+		"2019-01-02T15:00:00Z,20\n2019-01-02T15:01:00Z,80\n2019-01-02T15:02:00Z,85\n2019-01-02T15:03:00Z,90\n")
+	writeFile(t, filepath.Join(dir, "default", "web-2.json"), `[
+		{"time": "2019-01-02T15:00:00Z", "value": 10},
+		{"time": "2019-01-02T15:01:00Z", "value": 75}]`)
+	writeFile(t, filepath.Join(dir, "default", "worker-1.csv"), "1546441200,5\n1546441380,5\n")
+	writeFile(t, filepath.Join(dir, "default", "notes.txt"), "ignored")
+
+	source, err := NewFileMetricsSource(dir, false)
+	assert.NoError(t, err)
+	source.now = func() time.Time { return time.Date(2019, 1, 2, 15, 3, 30, 0, time.UTC) }
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+		PodLabelIndex:        PodLabelIndexFunc,
+	})
+	for name, app := range map[string]string{"web-1": "web", "web-2": "web", "worker-1": "worker"} {
+		indexer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name,
+			Labels: map[string]string{"app": app}}})
+	}
+	calculator := NewReplicaCalculator(NewIndexedPodLister(indexer), source)
+
+	testCases := []struct {
+		name        string
+		selector    string
+		evaluations int32
+		expected    int32
+	}{
+		{name: "scales up busy pods", selector: "app=web", evaluations: 3, expected: 3},
+		{name: "older idle samples are not evaluated", selector: "app=web", evaluations: 4, expected: 2},
+		{name: "scales down idle pods", selector: "app=worker", evaluations: 4, expected: 1},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			selector, err := labels.Parse(c.selector)
+			assert.NoError(t, err)
+			replicas, err := calculator.GetResourceReplicas("default", c.evaluations, 2, 10, 70, 1, 1, selector)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, replicas)
+		})
+	}
+}
+
+func TestFileMetricsSourceSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics.json")
+	writeFile(t, path, `{"default/web-1": [
+		{"time": "2019-01-02T15:00:00Z", "value": 10},
+		{"time": "2019-01-02T15:02:00Z", "value": 30}]}`)
+
+	t.Run("samples per minute", func(t *testing.T) {
+		source, err := NewFileMetricsSource(path, false)
+		assert.NoError(t, err)
+		source.now = func() time.Time { return time.Date(2019, 1, 2, 15, 3, 0, 0, time.UTC) }
+		metrics, err := source.GetPodMetrics("default", []string{"web-1", "web-2"}, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]int{"web-1": {10, 10, 30, 30}}, metrics)
+	})
+
+	t.Run("stale samples are left out", func(t *testing.T) {
+		source, err := NewFileMetricsSource(path, false)
+		assert.NoError(t, err)
+		source.now = func() time.Time { return time.Date(2019, 1, 2, 15, 9, 0, 0, time.UTC) }
+		metrics, err := source.GetPodMetrics("default", []string{"web-1"}, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]int{"web-1": {30}}, metrics)
+	})
+
+	t.Run("replay starts at the creation", func(t *testing.T) {
+		source, err := NewFileMetricsSource(path, true)
+		assert.NoError(t, err)
+		start := source.start
+		source.now = func() time.Time { return start.Add(2 * time.Minute) }
+		metrics, err := source.GetPodMetrics("default", []string{"web-1"}, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(metrics))
+		assert.Contains(t, metrics["web-1"], 10)
+	})
+
+	t.Run("reloads changed files", func(t *testing.T) {
+		source, err := NewFileMetricsSource(path, false)
+		assert.NoError(t, err)
+		source.now = func() time.Time { return time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC) }
+		changed, err := source.reload()
+		assert.NoError(t, err)
+		assert.False(t, changed)
+
+		writeFile(t, path, `{"default/web-1": [{"time": "2019-01-02T15:00:00Z", "value": 50}]}`)
+		later := time.Now().Add(time.Minute)
+		assert.NoError(t, os.Chtimes(path, later, later))
+		changed, err = source.reload()
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		metrics, err := source.GetPodMetrics("default", []string{"web-1"}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]int{"web-1": {50}}, metrics)
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		invalid := filepath.Join(dir, "invalid.json")
+		writeFile(t, invalid, `{"web-1": []}`)
+		_, err := NewFileMetricsSource(invalid, false)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,117 @@
+package replicacalculator
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// PodLabelIndex indexes pods by every `namespace/key=value` label pair they carry
+	PodLabelIndex = "namespaceLabel"
+)
+
+// PodLister lists the pods in a namespace which match a selector
+type PodLister interface {
+	List(namespace string, selector labels.Selector) ([]*corev1.Pod, error)
+}
+
+// PodLabelIndexFunc is the index function for PodLabelIndex
+func PodLabelIndexFunc(obj interface{}) ([]string, error) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(m.GetLabels()))
+	for k, v := range m.GetLabels() {
+		keys = append(keys, labelIndexKey(m.GetNamespace(), k, v))
+	}
+	return keys, nil
+}
+
+// NewIndexedPodLister returns a PodLister which looks pods up in the indexer of a pod informer.
+// The indexer must contain the cache.NamespaceIndex and the PodLabelIndex.
+func NewIndexedPodLister(indexer cache.Indexer) PodLister {
+	return &indexedPodLister{indexer: indexer}
+}
+
+type indexedPodLister struct {
+	indexer cache.Indexer
+}
+
+// List narrows the candidates down with the label index when the selector requires a single
+// label value, and with the namespace index otherwise. The candidates are then matched
+// against the complete selector.
+func (l *indexedPodLister) List(namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	var (
+		objects []interface{}
+		err     error
+	)
+	if key, ok := selectorIndexKey(namespace, selector); ok {
+		objects, err = l.indexer.ByIndex(PodLabelIndex, key)
+	} else {
+		objects, err = l.indexer.ByIndex(cache.NamespaceIndex, namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]*corev1.Pod, 0, len(objects))
+	for _, o := range objects {
+		pod, ok := o.(*corev1.Pod)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object in the pod index: %T", o)
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// NewClientPodLister returns a PodLister which lists the pods from the API server. It is meant
+// for one off evaluations where starting a pod informer is not worth it.
+func NewClientPodLister(client kubernetes.Interface) PodLister {
+	return &clientPodLister{client: client}
+}
+
+type clientPodLister struct {
+	client kubernetes.Interface
+}
+
+func (l *clientPodLister) List(namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	list, err := l.client.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*corev1.Pod, len(list.Items))
+	for i := range list.Items {
+		pods[i] = &list.Items[i]
+	}
+	return pods, nil
+}
+
+func selectorIndexKey(namespace string, selector labels.Selector) (string, bool) {
+	requirements, selectable := selector.Requirements()
+	if !selectable {
+		return "", false
+	}
+	for _, r := range requirements {
+		switch r.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			if r.Values().Len() == 1 {
+				return labelIndexKey(namespace, r.Key(), r.Values().List()[0]), true
+			}
+		}
+	}
+	return "", false
+}
+
+func labelIndexKey(namespace, key, value string) string {
+	return fmt.Sprintf("%s/%s=%s", namespace, key, value)
+}
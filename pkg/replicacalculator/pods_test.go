@@ -0,0 +1,59 @@
+package replicacalculator
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"sort"
+	"testing"
+)
+
+func TestIndexedPodLister(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+		PodLabelIndex:        PodLabelIndexFunc,
+	})
+	pods := []struct {
+		namespace, name string
+		labels          map[string]string
+	}{
+		{"default", "nginx-1", map[string]string{"app": "nginx", "tier": "web"}},
+		{"default", "nginx-2", map[string]string{"app": "nginx", "tier": "canary"}},
+		{"default", "redis-1", map[string]string{"app": "redis"}},
+		{"other", "nginx-3", map[string]string{"app": "nginx", "tier": "web"}},
+	}
+	for _, p := range pods {
+		indexer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: p.namespace, Name: p.name, Labels: p.labels}})
+	}
+
+	testCases := []struct {
+		name      string
+		namespace string
+		selector  string
+		expected  []string
+	}{
+		{name: "equality selector", namespace: "default", selector: "app=nginx", expected: []string{"nginx-1", "nginx-2"}},
+		{name: "multiple requirements", namespace: "default", selector: "app=nginx,tier=web", expected: []string{"nginx-1"}},
+		{name: "set selector", namespace: "default", selector: "app in (nginx, redis)", expected: []string{"nginx-1", "nginx-2", "redis-1"}},
+		{name: "other namespace", namespace: "other", selector: "app=nginx", expected: []string{"nginx-3"}},
+		{name: "no match", namespace: "default", selector: "app=postgres", expected: []string{}},
+	}
+
+	lister := NewIndexedPodLister(indexer)
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			selector, err := labels.Parse(c.selector)
+			assert.NoError(t, err)
+			result, err := lister.List(c.namespace, selector)
+			assert.NoError(t, err)
+			names := []string{}
+			for _, p := range result {
+				names = append(names, p.Name)
+			}
+			sort.Strings(names)
+			assert.Equal(t, c.expected, names)
+		})
+	}
+}
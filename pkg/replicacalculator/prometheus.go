@@ -7,6 +7,10 @@ import (
 	prometheusapi "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,12 +18,33 @@ import (
 const (
 	prometheusQuery = `sum(rate(container_cpu_usage_seconds_total{pod_name=~"%s", namespace="%s"}[1m])) by(pod_name) / 
 		sum(kube_pod_container_resource_requests_cpu_cores{pod_name=~"%s", namespace="%s"}) by (pod_name)`
+	// demandQuery is the CPU usage of all the pods of a workload divided by the request of a single
+	// pod. The pods are selected by joining podLabelsQuery.
+	demandQuery = `sum(rate(container_cpu_usage_seconds_total{namespace="%[1]s"}[5m]) * on(pod_name) group_left() %[2]s) /
+		avg(kube_pod_container_resource_requests_cpu_cores{namespace="%[1]s"} * on(pod_name) group_left() %[2]s)`
+	// podLabelsQuery selects the pods by the labels exported by kube-state-metrics
+	podLabelsQuery = `max by(pod_name) (kube_pod_labels{namespace="%s"%s})`
 )
 
+// invalidLabelChars are replaced by kube-state-metrics in the names of the pod labels
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
 type MetricsSource interface {
 	GetPodMetrics(namespace string, podIDs []string, evaluations int32) (map[string][]int, error)
 }
 
+// ActivationSource evaluates queries which are not tied to the pods of a target. It is used to
+// decide whether a target without any pods is in use.
+type ActivationSource interface {
+	GetActivationValue(query string) (float64, error)
+}
+
+// DemandSource returns the history of the demand of a workload. The demand is the CPU usage
+// of all its pods in percent of the request of a single pod.
+type DemandSource interface {
+	GetDemandHistory(namespace string, selector labels.Selector, start, end time.Time, step time.Duration) ([]float64, error)
+}
+
 func NewPrometheusMetricsSource(prometheusClient prometheusclient.Client) MetricsSource {
 	prometheusAPI := prometheusapi.NewAPI(prometheusClient)
 	return &prometheusMetricsSource{prometheusClient: prometheusClient, prometheusAPI: prometheusAPI}
@@ -64,3 +89,104 @@ func (m *prometheusMetricsSource) GetPodMetrics(namespace string, podIDs []strin
 	}
 	return mapResults, nil
 }
+
+// GetActivationValue runs the query at the current time and returns the sum of its results. A
+// query without results is treated as zero.
+func (m *prometheusMetricsSource) GetActivationValue(query string) (float64, error) {
+	log.Debugf("activation query: %s", query)
+	result, err := m.prometheusAPI.Query(context.TODO(), query, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	switch v := result.(type) {
+	case *model.Scalar:
+		return float64(v.Value), nil
+	case model.Vector:
+		var sum float64
+		for _, sample := range v {
+			sum += float64(sample.Value)
+		}
+		return sum, nil
+	}
+	return 0, fmt.Errorf("unexpected return type from the prometheus api call: %v", result.Type())
+}
+
+// GetDemandHistory returns one value for every step between start and end of the pods which
+// match the selector. Gaps in the history are filled with the previous value.
+func (m *prometheusMetricsSource) GetDemandHistory(namespace string, selector labels.Selector, start, end time.Time,
+	step time.Duration) ([]float64, error) {
+	pods, err := selectPods(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(demandQuery, namespace, pods)
+	log.Debugf("demand query: %s", query)
+
+	results, err := m.prometheusAPI.QueryRange(context.TODO(), query, prometheusapi.Range{Start: start, End: end, Step: step})
+	if err != nil {
+		return nil, err
+	}
+	matrixResult, ok := results.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unexpected return type from the prometheus api call: %v", results.Type())
+	}
+	if len(matrixResult) == 0 || len(matrixResult[0].Values) == 0 {
+		return nil, fmt.Errorf("no demand history for the pods %s in %s", selector, namespace)
+	}
+	return fillSteps(matrixResult[0].Values, start, end, step), nil
+}
+
+// selectPods returns the query of the pods which match the selector. The history includes pods
+// which no longer exist, so they cannot be listed like the pods of the reactive scaling.
+func selectPods(namespace string, selector labels.Selector) (string, error) {
+	requirements, selectable := selector.Requirements()
+	if !selectable || len(requirements) == 0 {
+		return "", fmt.Errorf("the selector %q does not select any pods", selector)
+	}
+	var matchers []string
+	for _, r := range requirements {
+		name := "label_" + invalidLabelChars.ReplaceAllString(r.Key(), "_")
+		values := r.Values().List()
+		for i := range values {
+			values[i] = regexp.QuoteMeta(values[i])
+		}
+		pattern := strconv.Quote(strings.Join(values, "|"))
+		switch r.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			matchers = append(matchers, name+"=~"+pattern)
+		case selection.NotEquals, selection.NotIn:
+			matchers = append(matchers, name+"!~"+pattern)
+		case selection.Exists:
+			matchers = append(matchers, name+`!=""`)
+		case selection.DoesNotExist:
+			matchers = append(matchers, name+`=""`)
+		default:
+			return "", fmt.Errorf("the operator %s of the selector %q is not supported", r.Operator(), selector)
+		}
+	}
+	return fmt.Sprintf(podLabelsQuery, namespace, ", "+strings.Join(matchers, ", ")), nil
+}
+
+// fillSteps places the samples on a grid of steps between start and end. Steps without a
+// sample take the value of the previous one, or of the first sample at the beginning.
+func fillSteps(samples []model.SamplePair, start, end time.Time, step time.Duration) []float64 {
+	values := make([]float64, int(end.Sub(start)/step)+1)
+	present := make([]bool, len(values))
+	for _, s := range samples {
+		i := int(s.Timestamp.Time().Sub(start) / step)
+		if i < 0 || i >= len(values) {
+			continue
+		}
+		values[i] = float64(s.Value) * 100
+		present[i] = true
+	}
+	last := float64(samples[0].Value) * 100
+	for i := range values {
+		if present[i] {
+			last = values[i]
+		} else {
+			values[i] = last
+		}
+	}
+	return values
+}
@@ -0,0 +1,43 @@
+package replicacalculator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+	"testing"
+)
+
+func TestSelectPods(t *testing.T) {
+	testCases := []struct {
+		name     string
+		selector string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "match labels",
+			selector: "app=web,tier=frontend",
+			expected: `max by(pod_name) (kube_pod_labels{namespace="shop", label_app=~"web", label_tier=~"frontend"})`,
+		},
+		{
+			name:     "expressions",
+			selector: "app.kubernetes.io/name in (web,web.v2),track!=canary,!legacy",
+			expected: `max by(pod_name) (kube_pod_labels{namespace="shop", label_app_kubernetes_io_name=~"web|web\\.v2", ` +
+				`label_legacy="", label_track!~"canary"})`,
+		},
+		{name: "everything", selector: "", err: true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			selector, err := labels.Parse(c.selector)
+			assert.NoError(t, err)
+			query, err := selectPods("shop", selector)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, query)
+		})
+	}
+}
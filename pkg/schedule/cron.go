@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five field cron expression: minute, hour, day of month, month and day of
+// week. Every field accepts *, single values, ranges (1-5), steps (*/15, 8-18/2) and comma
+// separated lists of those. Months and days of the week can also be given by their first three
+// letters. Like in cron, when both the day of month and the day of week are restricted a day
+// matches if either of them matches.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+type field struct {
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField = field{min: 0, max: 59}
+	hourField   = field{min: 0, max: 23}
+	domField    = field{min: 1, max: 31}
+	monthField  = field{min: 1, max: 12, names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug",
+		"sep", "oct", "nov", "dec"}}
+	// 7 is accepted as Sunday as well
+	dowField = field{min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// ParseCron parses a five field cron expression
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in %q but got %d", expr, len(fields))
+	}
+	c := &Cron{domStar: fields[2] == "*", dowStar: fields[4] == "*"}
+	var err error
+	if c.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid minute: %v", err)
+	}
+	if c.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, fmt.Errorf("invalid hour: %v", err)
+	}
+	if c.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %v", err)
+	}
+	if c.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, fmt.Errorf("invalid month: %v", err)
+	}
+	if c.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, fmt.Errorf("invalid day of week: %v", err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// parse returns a bit set of the values matched by the field expression
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			var err error
+			if low, err = f.value(part); err != nil {
+				return 0, err
+			}
+			if step == 1 {
+				high = low
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%d is out of the range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t which matches the expression, in the location of t. It
+// returns the zero time if there is no such time within five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,141 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data is not available: %v", err)
+	}
+	// a Friday
+	friday := time.Date(2019, 1, 4, 7, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "every minute",
+			expr:     "* * * * *",
+			from:     friday,
+			expected: time.Date(2019, 1, 4, 7, 31, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekday mornings on a friday",
+			expr:     "0 8 * * 1-5",
+			from:     friday,
+			expected: time.Date(2019, 1, 4, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekday mornings skip the weekend",
+			expr:     "0 8 * * mon-fri",
+			from:     time.Date(2019, 1, 4, 8, 0, 0, 0, time.UTC),
+			expected: time.Date(2019, 1, 7, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "steps",
+			expr:     "*/15 9-17/4 * * *",
+			from:     friday,
+			expected: time.Date(2019, 1, 4, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "lists",
+			expr:     "5,35 7 * * *",
+			from:     friday,
+			expected: time.Date(2019, 1, 4, 7, 35, 0, 0, time.UTC),
+		},
+		{
+			name:     "sunday as 7",
+			expr:     "0 0 * * 7",
+			from:     friday,
+			expected: time.Date(2019, 1, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of month or day of week",
+			expr:     "0 0 10 * sat",
+			from:     friday,
+			expected: time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "month and year rollover",
+			expr:     "0 0 1 feb *",
+			from:     time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "time zone",
+			expr:     "0 8 * * *",
+			from:     friday.In(berlin),
+			expected: time.Date(2019, 1, 5, 8, 0, 0, 0, berlin),
+		},
+		{
+			name: "never",
+			expr: "0 0 31 feb *",
+			from: friday,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			cron, err := ParseCron(c.expr)
+			assert.NoError(t, err)
+			next := cron.Next(c.from)
+			assert.True(t, c.expected.Equal(next), "expected %s but got %s", c.expected, next)
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	cron, _ := ParseCron("0 8 * * 1-5")
+	w := Window{Cron: cron, Duration: 14 * time.Hour, Location: time.UTC}
+
+	testCases := []struct {
+		name     string
+		now      time.Time
+		active   bool
+		end      time.Time
+		boundary time.Time
+	}{
+		{
+			name:     "before the start",
+			now:      time.Date(2019, 1, 4, 7, 0, 0, 0, time.UTC),
+			boundary: time.Date(2019, 1, 4, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "at the start",
+			now:      time.Date(2019, 1, 4, 8, 0, 0, 0, time.UTC),
+			active:   true,
+			end:      time.Date(2019, 1, 4, 22, 0, 0, 0, time.UTC),
+			boundary: time.Date(2019, 1, 4, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "after the end",
+			now:      time.Date(2019, 1, 4, 22, 0, 0, 0, time.UTC),
+			boundary: time.Date(2019, 1, 7, 8, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			active, end := w.Active(c.now)
+			assert.Equal(t, c.active, active)
+			assert.True(t, c.end.Equal(end), "expected end %s but got %s", c.end, end)
+			boundary := w.NextBoundary(c.now)
+			assert.True(t, c.boundary.Equal(boundary), "expected boundary %s but got %s", c.boundary, boundary)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package schedule
+
+import (
+	"time"
+)
+
+// Window is a recurring period which starts at the times of a cron expression and lasts for a
+// fixed duration
+type Window struct {
+	Cron     *Cron
+	Duration time.Duration
+	Location *time.Location
+}
+
+// Active returns true if now is inside an occurrence of the window and when that occurrence ends
+func (w Window) Active(now time.Time) (bool, time.Time) {
+	now = now.In(w.Location)
+	var end time.Time
+	// every occurrence which started within the duration before now could still be active
+	for start := w.Cron.Next(now.Add(-w.Duration).Add(-time.Minute)); !start.IsZero() && !start.After(now); start = w.Cron.Next(start) {
+		if e := start.Add(w.Duration); e.After(now) && e.After(end) {
+			end = e
+		}
+	}
+	return !end.IsZero(), end
+}
+
+// NextBoundary returns the next time after now at which the window starts or ends
+func (w Window) NextBoundary(now time.Time) time.Time {
+	next := w.Cron.Next(now.In(w.Location))
+	if active, end := w.Active(now); active && (next.IsZero() || end.Before(next)) {
+		return end
+	}
+	return next
+}
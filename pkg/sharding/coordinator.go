@@ -0,0 +1,210 @@
+package sharding
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	coordinationv1beta1 "k8s.io/api/coordination/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	// GroupLabel is set on every shard Lease and holds the name of the shard group
+	GroupLabel = "arjunnaik.in/shard-group"
+
+	// releaseTimeout bounds how long the shutdown waits for the Lease to be deleted
+	releaseTimeout = 5 * time.Second
+)
+
+// Coordinator keeps a Lease alive for this controller replica and watches the Leases of the
+// other replicas in the same group. The live replicas are placed on a consistent hash ring
+// which decides which replica owns a Scaler key.
+type Coordinator struct {
+	kubeClient    kubernetes.Interface
+	namespace     string
+	group         string
+	identity      string
+	leaseDuration time.Duration
+
+	mu        sync.RWMutex
+	ring      *Ring
+	synced    bool
+	lastRenew time.Time
+	handlers  []func()
+}
+
+// NewCoordinator creates a coordinator which holds the Lease `<group>-<identity>` in the namespace
+func NewCoordinator(kubeClient kubernetes.Interface, namespace, group, identity string,
+	leaseDuration time.Duration) *Coordinator {
+	return &Coordinator{
+		kubeClient:    kubeClient,
+		namespace:     namespace,
+		group:         group,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+		ring:          NewRing(nil),
+	}
+}
+
+// AddMembershipHandler registers a function which is called after the set of live replicas changes
+func (c *Coordinator) AddMembershipHandler(handler func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = append(c.handlers, handler)
+}
+
+// Owns returns true if this replica is responsible for the key
+func (c *Coordinator) Owns(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ring.Owner(key) == c.identity
+}
+
+// HasSynced returns true once the membership has been read at least once
+func (c *Coordinator) HasSynced() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.synced
+}
+
+// Run renews the Lease and refreshes the membership until stopCh is closed. On shutdown the
+// Lease is deleted so that the remaining replicas take over the keys without waiting for it to
+// expire. Run only returns once the Lease is released or the release timed out, so the process
+// should wait for it before it exits.
+func (c *Coordinator) Run(stopCh <-chan struct{}) {
+	log.Infof("starting shard coordinator %s in group %s", c.identity, c.group)
+	wait.Until(c.sync, c.leaseDuration/3, stopCh)
+
+	released := make(chan error, 1)
+	go func() {
+		released <- c.kubeClient.CoordinationV1beta1().Leases(c.namespace).Delete(c.leaseName(), &metav1.DeleteOptions{})
+	}()
+	select {
+	case err := <-released:
+		if err != nil && !errors.IsNotFound(err) {
+			log.Errorf("failed to release shard lease %s: %v", c.leaseName(), err)
+			return
+		}
+		log.Infof("released shard lease %s", c.leaseName())
+	case <-time.After(releaseTimeout):
+		log.Errorf("timed out releasing shard lease %s", c.leaseName())
+	}
+}
+
+// Namespace returns the namespace of the Leases
+func (c *Coordinator) Namespace() string {
+	return c.namespace
+}
+
+func (c *Coordinator) sync() {
+	if err := c.renew(); err != nil {
+		log.Errorf("failed to renew shard lease %s: %v", c.leaseName(), err)
+	} else {
+		c.mu.Lock()
+		c.lastRenew = time.Now()
+		c.mu.Unlock()
+	}
+
+	members, err := c.liveMembers()
+	if err != nil {
+		log.Errorf("failed to list shard leases: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	changed := !reflect.DeepEqual(members, c.ring.Members())
+	if changed {
+		log.Infof("shard membership changed: %v", members)
+		c.ring = NewRing(members)
+	}
+	c.synced = true
+	handlers := c.handlers
+	c.mu.Unlock()
+
+	if changed {
+		for _, h := range handlers {
+			h()
+		}
+	}
+}
+
+func (c *Coordinator) renew() error {
+	leases := c.kubeClient.CoordinationV1beta1().Leases(c.namespace)
+	now := metav1.NewMicroTime(time.Now())
+	durationSeconds := int32(c.leaseDuration.Seconds())
+
+	lease, err := leases.Get(c.leaseName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = leases.Create(&coordinationv1beta1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.leaseName(),
+				Namespace: c.namespace,
+				Labels:    map[string]string{GroupLabel: c.group},
+			},
+			Spec: coordinationv1beta1.LeaseSpec{
+				HolderIdentity:       &c.identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	lease = lease.DeepCopy()
+	lease.Spec.HolderIdentity = &c.identity
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(lease)
+	return err
+}
+
+// liveMembers returns the sorted identities of the replicas whose Leases have not expired.
+// This replica is only included while its own renewals are succeeding so that it stops
+// claiming keys at about the same time the others take them over.
+func (c *Coordinator) liveMembers() ([]string, error) {
+	selector := labels.SelectorFromSet(labels.Set{GroupLabel: c.group})
+	list, err := c.kubeClient.CoordinationV1beta1().Leases(c.namespace).List(metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var members []string
+	for _, lease := range list.Items {
+		spec := lease.Spec
+		if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+			continue
+		}
+		if *spec.HolderIdentity == c.identity {
+			continue
+		}
+		expiry := spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+		if expiry.After(now) {
+			members = append(members, *spec.HolderIdentity)
+		}
+	}
+
+	c.mu.RLock()
+	selfAlive := c.lastRenew.Add(c.leaseDuration).After(now)
+	c.mu.RUnlock()
+	if selfAlive {
+		members = append(members, c.identity)
+	}
+	return NewRing(members).Members(), nil
+}
+
+func (c *Coordinator) leaseName() string {
+	return fmt.Sprintf("%s-%s", c.group, c.identity)
+}
@@ -0,0 +1,89 @@
+package sharding
+
+import (
+	"github.com/stretchr/testify/assert"
+	coordinationv1beta1 "k8s.io/api/coordination/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func newLease(name, group, holder string, renewed time.Time) *coordinationv1beta1.Lease {
+	duration := int32(15)
+	renewTime := metav1.NewMicroTime(renewed)
+	return &coordinationv1beta1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system", Labels: map[string]string{GroupLabel: group}},
+		Spec: coordinationv1beta1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			RenewTime:            &renewTime,
+		},
+	}
+}
+
+func TestSyncMembership(t *testing.T) {
+	now := time.Now()
+	client := fake.NewSimpleClientset(
+		newLease("scaler-b", "scaler", "b", now),
+		newLease("scaler-c", "scaler", "c", now.Add(-time.Minute)),
+		newLease("other-d", "other", "d", now),
+	)
+	c := NewCoordinator(client, "kube-system", "scaler", "a", 15*time.Second)
+	changes := 0
+	c.AddMembershipHandler(func() { changes++ })
+	assert.False(t, c.HasSynced())
+
+	c.sync()
+	assert.True(t, c.HasSynced())
+	// the expired Lease of c and the Lease of the other group are ignored
+	assert.Equal(t, []string{"a", "b"}, c.ring.Members())
+	assert.Equal(t, 1, changes)
+
+	lease, err := client.CoordinationV1beta1().Leases("kube-system").Get("scaler-a", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", *lease.Spec.HolderIdentity)
+	assert.Equal(t, "scaler", lease.Labels[GroupLabel])
+
+	// a sync without a change in the membership does not call the handlers
+	c.sync()
+	assert.Equal(t, 1, changes)
+
+	owned := 0
+	for _, key := range []string{"default/web", "default/api", "batch/worker", "batch/cron", "prod/web", "prod/api"} {
+		if c.Owns(key) {
+			owned++
+			assert.Equal(t, "a", c.ring.Owner(key))
+		}
+	}
+	assert.True(t, owned > 0 && owned < 6, "the keys are split between a and b")
+
+	err = client.CoordinationV1beta1().Leases("kube-system").Delete("scaler-b", &metav1.DeleteOptions{})
+	assert.NoError(t, err)
+	c.sync()
+	assert.Equal(t, []string{"a"}, c.ring.Members())
+	assert.Equal(t, 2, changes)
+	assert.True(t, c.Owns("default/web"))
+}
+
+func TestRunReleasesLease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := NewCoordinator(client, "kube-system", "scaler", "a", 3*time.Second)
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		c.Run(stopCh)
+		close(done)
+	}()
+	for !c.HasSynced() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, err := client.CoordinationV1beta1().Leases("kube-system").Get("scaler-a", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	close(stopCh)
+	<-done
+	_, err = client.CoordinationV1beta1().Leases("kube-system").Get("scaler-a", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err), "the Lease is deleted before Run returns")
+}
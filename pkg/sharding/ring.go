@@ -0,0 +1,68 @@
+package sharding
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// defaultVirtualNodes is the number of points each member gets on the ring. More points
+// spread the keys more evenly at the cost of a slightly larger ring.
+const defaultVirtualNodes = 100
+
+// Ring is a consistent hash ring which maps keys to members. Adding or removing a member
+// only moves the keys which hash next to that member's points.
+type Ring struct {
+	points  []uint32
+	owners  map[uint32]string
+	members []string
+}
+
+// NewRing creates a ring for the given members
+func NewRing(members []string) *Ring {
+	r := &Ring{owners: make(map[uint32]string)}
+	for _, m := range members {
+		r.members = append(r.members, m)
+		for i := 0; i < defaultVirtualNodes; i++ {
+			p := hashKey(m + "#" + strconv.Itoa(i))
+			// on the rare collision the lexically smaller member wins so every replica builds
+			// the same ring regardless of the order it listed the members in
+			if existing, ok := r.owners[p]; ok && existing < m {
+				continue
+			}
+			if _, ok := r.owners[p]; !ok {
+				r.points = append(r.points, p)
+			}
+			r.owners[p] = m
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	sort.Strings(r.members)
+	return r
+}
+
+// Owner returns the member which owns the key. It returns an empty string if the ring is empty.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hashKey(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+// Members returns the sorted list of members on the ring
+func (r *Ring) Members() []string {
+	return r.members
+}
+
+// hashKey uses sha1 rather than fnv because the keys and the virtual node names are very
+// similar strings and fnv leaves them clustered on the ring
+func hashKey(key string) uint32 {
+	sum := sha1.Sum([]byte(key))
+	return binary.BigEndian.Uint32(sum[:4])
+}
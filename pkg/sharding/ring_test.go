@@ -0,0 +1,57 @@
+package sharding
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRingOwner(t *testing.T) {
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("namespace-%d/scaler-%d", i%7, i)
+	}
+
+	testCases := []struct {
+		name    string
+		members []string
+	}{
+		{name: "single member", members: []string{"a"}},
+		{name: "three members", members: []string{"a", "b", "c"}},
+		{name: "unsorted members", members: []string{"c", "a", "b"}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			ring := NewRing(c.members)
+			counts := map[string]int{}
+			for _, k := range keys {
+				owner := ring.Owner(k)
+				assert.Contains(t, c.members, owner)
+				counts[owner]++
+			}
+			for _, m := range c.members {
+				assert.True(t, counts[m] > len(keys)/(2*len(c.members)), "member %s only owns %d keys", m, counts[m])
+			}
+		})
+	}
+}
+
+func TestRingRebalance(t *testing.T) {
+	before := NewRing([]string{"a", "b", "c"})
+	after := NewRing([]string{"a", "b", "c", "d"})
+
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("default/scaler-%d", i)
+		if before.Owner(key) != after.Owner(key) {
+			moved++
+			assert.Equal(t, "d", after.Owner(key), "keys should only move to the new member")
+		}
+	}
+	assert.True(t, moved > 0 && moved < 500, "unexpected number of moved keys: %d", moved)
+}
+
+func TestEmptyRing(t *testing.T) {
+	assert.Equal(t, "", NewRing(nil).Owner("default/scaler"))
+}
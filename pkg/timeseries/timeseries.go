@@ -0,0 +1,169 @@
+package timeseries
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sample is a value at a point in time
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Series are samples ordered by time
+type Series []Sample
+
+// Load reads a series from a CSV or a JSON file depending on its extension
+func Load(path string) (Series, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return LoadCSV(f)
+	case ".json":
+		return LoadJSON(f)
+	}
+	return nil, fmt.Errorf("unknown format of %s. expected a .csv or a .json file", path)
+}
+
+// LoadCSV reads rows of a timestamp and a value. The timestamp is either RFC3339 or in seconds
+// since the epoch. A header row is skipped.
+func LoadCSV(r io.Reader) (Series, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var series Series
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		t, timeErr := parseTime(record[0])
+		value, valueErr := strconv.ParseFloat(record[1], 64)
+		if timeErr != nil || valueErr != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("invalid sample %v on line %d", record, line)
+		}
+		series = append(series, Sample{Time: t, Value: value})
+	}
+	series.Sort()
+	return series, nil
+}
+
+// LoadJSON reads either an array of samples like [{"time": "2019-01-02T15:04:05Z", "value": 150}]
+// or the response of a Prometheus range query. The values of all the series in a Prometheus
+// response are added up. Prometheus returns the usage as a fraction of the request, so its
+// values are converted to percent like the samples in an array.
+func LoadJSON(r io.Reader) (Series, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		var series Series
+		if err := json.Unmarshal(raw, &series); err != nil {
+			return nil, err
+		}
+		series.Sort()
+		return series, nil
+	}
+
+	var response struct {
+		Data struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Values [][2]interface{} `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, err
+	}
+	if response.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("expected a matrix but got %q", response.Data.ResultType)
+	}
+	sums := map[int64]float64{}
+	for _, result := range response.Data.Result {
+		for _, pair := range result.Values {
+			seconds, ok := pair[0].(float64)
+			text, textOK := pair[1].(string)
+			if !ok || !textOK {
+				return nil, fmt.Errorf("invalid sample %v", pair)
+			}
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, err
+			}
+			sums[int64(seconds*1000)] += value * 100
+		}
+	}
+	series := make(Series, 0, len(sums))
+	for ms, value := range sums {
+		series = append(series, Sample{Time: time.Unix(0, ms*int64(time.Millisecond)).UTC(), Value: value})
+	}
+	series.Sort()
+	return series, nil
+}
+
+// Between returns the samples from start up to but not including end. A zero time leaves
+// that side open.
+func (s Series) Between(start, end time.Time) Series {
+	var result Series
+	for _, sample := range s {
+		if (!start.IsZero() && sample.Time.Before(start)) || (!end.IsZero() && !sample.Time.Before(end)) {
+			continue
+		}
+		result = append(result, sample)
+	}
+	return result
+}
+
+// Resample returns one value for every step from the first to the last sample. A step takes
+// the value of the latest sample at or before it.
+func (s Series) Resample(step time.Duration) []float64 {
+	if len(s) == 0 {
+		return nil
+	}
+	start := s[0].Time
+	values := make([]float64, int(s[len(s)-1].Time.Sub(start)/step)+1)
+	next := 0
+	for i := range values {
+		t := start.Add(time.Duration(i) * step)
+		for next < len(s) && !s[next].Time.After(t) {
+			next++
+		}
+		values[i] = s[next-1].Value
+	}
+	return values
+}
+
+// Sort orders the samples by time
+func (s Series) Sort() {
+	sort.SliceStable(s, func(i, j int) bool { return s[i].Time.Before(s[j].Time) })
+}
+
+func parseTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Unix(0, int64(seconds*float64(time.Second))).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
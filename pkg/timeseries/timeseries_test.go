@@ -0,0 +1,53 @@
+package timeseries
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadCSV(t *testing.T) {
+	input := `timestamp,value
+2019-01-02T10:02:00Z,30
+1546423260,20.5
+2019-01-02T10:00:00Z,10
+`
+	series, err := LoadCSV(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Len(t, series, 3)
+	if len(series) == 3 {
+		assert.Equal(t, 10.0, series[0].Value)
+		assert.Equal(t, 20.5, series[1].Value)
+		assert.True(t, series[2].Time.Equal(time.Date(2019, 1, 2, 10, 2, 0, 0, time.UTC)))
+	}
+
+	_, err = LoadCSV(strings.NewReader("2019-01-02T10:00:00Z,10\nyesterday,5\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadJSON(t *testing.T) {
+	samples := `[{"time": "2019-01-02T10:01:00Z", "value": 200}, {"time": "2019-01-02T10:00:00Z", "value": 100}]`
+	series, err := LoadJSON(strings.NewReader(samples))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{100, 200}, series.Resample(time.Minute))
+
+	prometheus := `{"status": "success", "data": {"resultType": "matrix", "result": [
+		{"metric": {"pod_name": "web-1"}, "values": [[1546423200, "0.5"], [1546423260, "0.75"]]},
+		{"metric": {"pod_name": "web-2"}, "values": [[1546423200, "0.25"]]}
+	]}}`
+	series, err = LoadJSON(strings.NewReader(prometheus))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{75, 75}, series.Resample(time.Minute), "the fractions are converted to percent")
+}
+
+func TestResample(t *testing.T) {
+	start := time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC)
+	series := Series{
+		{Time: start, Value: 1},
+		{Time: start.Add(90 * time.Second), Value: 2},
+		{Time: start.Add(4 * time.Minute), Value: 3},
+	}
+	assert.Equal(t, []float64{1, 1, 2, 2, 3}, series.Resample(time.Minute))
+	assert.Len(t, series.Between(start.Add(time.Minute), start.Add(4*time.Minute)), 1)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/arjunrn/simple-scaler/pkg/httpauth"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"k8s.io/client-go/kubernetes"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// newAuthenticator accepts the token in the file, whose holder may do everything, or, when no
+// file is given, Kubernetes tokens whose users are authorized with SubjectAccessReviews.
+// Kubernetes tokens are only accepted over TLS because they are valid for the API server as well.
+func newAuthenticator(kubeClient kubernetes.Interface, tokenFile string, secure bool) (httpauth.Authenticator, httpauth.Authorizer, error) {
+	if tokenFile == "" {
+		if !secure {
+			return nil, nil, fmt.Errorf("Kubernetes tokens are only accepted over TLS, either configure a certificate or a token file")
+		}
+		return httpauth.NewTokenReviewAuthenticator(kubeClient), httpauth.NewSubjectAccessReviewAuthorizer(kubeClient), nil
+	}
+	data, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return nil, nil, fmt.Errorf("the token file %s is empty", tokenFile)
+	}
+	return httpauth.NewStaticAuthenticator(token), httpauth.AllowAll(), nil
+}
+
+// serve runs an HTTP server on the address until the channel is closed
+func serve(name, address string, handler http.Handler, stopCh <-chan struct{}) {
+	server := newServer(address, handler, stopCh)
+	log.Infof("serving the %s on %s", name, address)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("failed to serve the %s: %s", name, err.Error())
+	}
+}
+
+// serveMaybeTLS runs an HTTPS server on the address when a certificate is given and an HTTP
+// server otherwise
+func serveMaybeTLS(name, address string, handler http.Handler, certFile, keyFile string, stopCh <-chan struct{}) {
+	if certFile == "" {
+		serve(name, address, handler, stopCh)
+		return
+	}
+	serveTLS(name, address, handler, certFile, keyFile, "", stopCh)
+}
+
+// serveTLS runs an HTTPS server on the address until the channel is closed. When a client CA is
+// given only clients with a certificate which is signed by it are accepted.
+func serveTLS(name, address string, handler http.Handler, certFile, keyFile, clientCAFile string,
+	stopCh <-chan struct{}) {
+	server := newServer(address, handler, stopCh)
+	server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCAFile != "" {
+		data, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			log.Fatalf("failed to read the client CA of the %s: %s", name, err.Error())
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			log.Fatalf("no certificates found in the client CA file %s", clientCAFile)
+		}
+		server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		server.TLSConfig.ClientCAs = clientCAs
+	}
+	log.Infof("serving the %s on %s", name, address)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("failed to serve the %s: %s", name, err.Error())
+	}
+}
+
+// newServer returns a server which is shut down when the channel is closed
+func newServer(address string, handler http.Handler, stopCh <-chan struct{}) *http.Server {
+	server := &http.Server{Addr: address, Handler: handler, ReadTimeout: 10 * time.Second, WriteTimeout: 30 * time.Second}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	return server
+}